@@ -0,0 +1,37 @@
+// Команда gencorpus создает синтетический корпус для замеров производительности vocab
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/terratensor/vocab/internal/synth"
+)
+
+func main() {
+	dir := flag.String("dir", "corpus", "Directory to write the synthetic corpus to")
+	files := flag.Int("files", 100, "Number of files")
+	lines := flag.Int("lines", 1000, "Number of lines per file")
+	words := flag.Int("words", 12, "Number of words per line")
+	vocabSize := flag.Int("vocab-size", 100000, "Number of distinct words")
+	exponent := flag.Float64("zipf", 1.1, "Zipf exponent of the word distribution (greater than 1)")
+	seed := flag.Int64("seed", 1, "Random seed; the same seed produces the same corpus")
+	flag.Parse()
+
+	startTime := time.Now()
+	err := synth.Generate(*dir, synth.Options{
+		Files:        *files,
+		LinesPerFile: *lines,
+		WordsPerLine: *words,
+		VocabSize:    *vocabSize,
+		Exponent:     *exponent,
+		Seed:         *seed,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Generated %d files in %s in %v\n", *files, *dir, time.Since(startTime))
+}
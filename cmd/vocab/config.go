@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Применение к флагам fs значений из JSON-файла конфигурации (YAML не поддерживается).
+// Ключи повторяют имена флагов (filter_punct или filter-punct), флаги, явно указанные
+// в командной строке, имеют приоритет над значениями из файла. Предупреждения
+// о неизвестных ключах выводятся в warn.
+func applyConfig(fs *flag.FlagSet, filePath string, warn io.Writer) error {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return fmt.Errorf("config file %s: YAML is not supported, use a JSON config file", filePath)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	// Флаги, явно указанные в командной строке
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for key, value := range values {
+		name := strings.ReplaceAll(key, "_", "-")
+		if name == "config" || fs.Lookup(name) == nil {
+			fmt.Fprintf(warn, "Warning: unknown config key %q\n", key)
+			continue
+		}
+		if explicit[name] {
+			continue
+		}
+
+		text, err := configValue(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for config key %q: %v", key, err)
+		}
+		if err := fs.Set(name, text); err != nil {
+			return fmt.Errorf("invalid value for config key %q: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// Преобразование значения из JSON в строковое значение флага
+func configValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		// Списки (например, inputs) задаются через запятую
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			text, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
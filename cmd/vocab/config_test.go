@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Явно указанные флаги имеют приоритет над файлом, неизвестные ключи вызывают предупреждение
+func TestApplyConfig(t *testing.T) {
+	fs := flag.NewFlagSet("vocab", flag.ContinueOnError)
+	output := fs.String("output", "vocab.txt", "")
+	sortType := fs.String("sort", "alpha", "")
+	lowercase := fs.Bool("lowercase", false, "")
+	filterPunct := fs.Bool("filter-punct", false, "")
+	minCount := fs.Int("min-count", 1, "")
+	inputs := fs.String("inputs", "", "")
+	fs.String("config", "", "")
+	if err := fs.Parse([]string{"-output=explicit.txt", "-lowercase=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeConfig(t, "vocab.json", `{
+		"output": "config.txt",
+		"sort": "freq",
+		"lowercase": true,
+		"filter_punct": true,
+		"min-count": 5,
+		"inputs": ["a.txt", "b.txt"],
+		"no_such_flag": 1
+	}`)
+	var warn bytes.Buffer
+	if err := applyConfig(fs, path, &warn); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+
+	if *output != "explicit.txt" || *lowercase {
+		t.Errorf("explicit flags overridden: output = %q, lowercase = %v", *output, *lowercase)
+	}
+	if *sortType != "freq" || !*filterPunct || *minCount != 5 || *inputs != "a.txt,b.txt" {
+		t.Errorf("config values not applied: sort = %q, filter-punct = %v, min-count = %d, inputs = %q", *sortType, *filterPunct, *minCount, *inputs)
+	}
+	if got := warn.String(); got != "Warning: unknown config key \"no_such_flag\"\n" {
+		t.Errorf("warnings = %q", got)
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	fs := flag.NewFlagSet("vocab", flag.ContinueOnError)
+	fs.Int("min-count", 1, "")
+
+	tests := map[string]struct {
+		name, content, want string
+	}{
+		"yaml":          {"vocab.yaml", "min_count: 5\n", "YAML is not supported"},
+		"invalid json":  {"vocab.json", "{min_count: 5}", "error parsing config file"},
+		"invalid value": {"vocab.json", `{"min_count": "many"}`, `invalid value for config key "min_count"`},
+	}
+	for name, tt := range tests {
+		err := applyConfig(fs, writeConfig(t, tt.name, tt.content), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", name, err, tt.want)
+		}
+	}
+}
@@ -1,108 +1,1001 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	_ "net/http/pprof" // Импортируем pprof
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/terratensor/vocab/internal/bpe"
+	"github.com/terratensor/vocab/internal/processor"
 	"github.com/terratensor/vocab/internal/tokenizer"
 )
 
+// Вывод информационных сообщений; при -quiet сообщения отбрасываются
+var out io.Writer = os.Stdout
+
 func main() {
 	// Определение флагов
-	sortType := flag.String("sort", "", "Sort vocabulary by frequency (freq) or alphabetically (alpha)")
+	sortType := flag.String("sort", "", "Sort vocabulary by frequency (freq), alphabetically (alpha) or by first appearance in the document (firstseen, only with -input-text)")
 	lowercase := flag.Bool("lowercase", false, "Convert tokens to lowercase")
 	filterPunct := flag.Bool("filter-punct", false, "Filter out punctuation tokens")
 	dirPath := flag.String("dir", "", "Path to the directory containing text files")
 	inputFile := flag.String("input", "", "Path to the input vocabulary file")
 	inputs := flag.String("inputs", "", "Comma-separated list of vocabulary files to merge")
-	outputFile := flag.String("output", "vocab_processed.txt", "Output file for the processed vocabulary")
-	maxGoroutines := flag.Int("max-goroutines", 0, "Maximum number of goroutines (default: number of CPUs)")
+	outputFile := flag.String("output", "vocab_processed.txt", "Output file for the processed vocabulary (- for stdout)")
+	maxGoroutines := flag.Int("max-goroutines", 0, "Maximum number of goroutines for processing files and loaded vocabularies (default: number of CPUs)")
 	pprofFlag := flag.Bool("pprof", false, "Enable pprof profiling")
+	csvColumn := flag.String("csv-column", "", "Text column of .csv/.tsv files (zero-based index or header name)")
+	jsonField := flag.String("json-field", "", "Dotted path to the text field of .jsonl/.ndjson files (e.g. .text)")
+	excludeCode := flag.Bool("exclude-code", false, "Skip code blocks and inline code of Markdown files (```, <pre>, `code`, <code>)")
+	separateCode := flag.String("separate-code", "", "Count code blocks and inline code of Markdown files into this separate vocabulary file instead of the main one")
+	markdownKeepURLs := flag.Bool("markdown-keep-urls", false, "Keep link and image URLs in .md/.markdown files (by default only link text is kept)")
+	ocr := flag.Bool("ocr", false, "Recognize PDF pages without a text layer (scans) with pdftoppm and tesseract")
+	ocrLang := flag.String("ocr-lang", "rus+eng", "Tesseract languages for -ocr (e.g. rus, eng or rus+eng)")
+	progressInterval := flag.Duration("progress-interval", 0, "Minimum interval between progress updates (e.g. 5s; default: 100ms in a terminal, 10s otherwise)")
+	whitelist := flag.String("whitelist", "", "File with tokens to keep (one per line)")
+	blacklist := flag.String("blacklist", "", "File with tokens to drop (one per line)")
+	bpeMerges := flag.Int("bpe-merges", 0, "Train a BPE merge table with the given number of merges")
+	bpeDir := flag.String("bpe-dir", "bpe", "Directory for the BPE merges.txt and vocab.json")
+	wordpieceSize := flag.Int("wordpiece-size", 0, "Generate a WordPiece vocabulary of the given size")
+	wordpieceOutput := flag.String("wordpiece-output", "wordpiece_vocab.txt", "Output file for the WordPiece vocabulary")
+	lemmatize := flag.Bool("lemmatize", false, "Reduce tokens to their lemma before counting (requires -lemma-dict)")
+	lemmaDict := flag.String("lemma-dict", "", "Lemma dictionary file with \"form lemma\" lines")
+	stem := flag.Bool("stem", false, "Reduce tokens to their stem with a Snowball stemmer")
+	stemLang := flag.String("stem-lang", "russian", "Stemmer language (russian or english)")
+	posDict := flag.String("pos-dict", "", "Part-of-speech dictionary file with \"form TAG\" lines (e.g. UD tags NOUN, VERB) for -pos-filter and -pos-tags")
+	posFilter := flag.String("pos-filter", "", "Comma-separated part-of-speech tags to keep, e.g. NOUN,VERB (requires -pos-dict)")
+	posTags := flag.Bool("pos-tags", false, "Append the part-of-speech tag to each token, e.g. дом_NOUN (requires -pos-dict)")
+	zipfOutput := flag.String("zipf-output", "", "Output file for the rank-frequency (Zipf) distribution")
+	splitHyphens := flag.Bool("split-hyphens", false, "Split hyphenated tokens into parts (из-за -> из, за)")
+	keepApostrophes := flag.Bool("keep-apostrophes", false, "Keep apostrophe-joined words as single tokens (don't)")
+	foldCase := flag.Bool("fold-case", false, "Merge case variants under the most frequent casing")
+	versionFlag := flag.Bool("version", false, "Print version and build information and exit")
+	configFile := flag.String("config", "", "JSON config file with flag values (explicit flags override it); YAML is not supported")
+	format := flag.String("format", "text", "Output format: text (token count), aligned (human-readable columns), tokens (tokens only, one per line) or sentencepiece (token<TAB>log-probability)")
+	thousandsSep := flag.Bool("thousands-sep", false, "Add thousands separators to counts in the aligned format")
+	humanCounts := flag.Bool("human-counts", false, "Abbreviate counts of a thousand and more (1.2M, 3.4B) in the aligned format and -stats-output")
+	detectLang := flag.Bool("detect-lang", false, "Detect the language of each file (ru, uk, en, de, fr, es) and write one vocabulary per language, e.g. out.ru.txt, out.en.txt and out.unknown.txt (requires -dir)")
+	langConfidence := flag.Float64("lang-confidence", 0.8, "Minimum share of function words of the detected language; other files go to the unknown vocabulary")
+	script := flag.String("script", "", "Keep only tokens whose letters belong to the script: cyrillic or latin")
+	scriptStrict := flag.Bool("script-strict", false, "With -script, also drop tokens containing non-letter characters")
+	provenance := flag.String("provenance", "", "Output file mapping each token to the files it occurs in (only with -dir)")
+	provenanceLimit := flag.Int("provenance-limit", 20, "Maximum number of files remembered per token for -provenance")
+	provenanceAbs := flag.Bool("provenance-abs", false, "Record absolute file paths in -provenance")
+	streamMerge := flag.Bool("stream-merge", false, "Merge -inputs by external sorting without holding the merged vocabulary in memory")
+	mergeChunkSize := flag.Int("merge-chunk-size", 1<<20, "Number of unique tokens per sorted run for -stream-merge")
+	tempDir := flag.String("temp-dir", "", "Directory for the temporary sorted runs of -stream-merge (default: system temp directory)")
+	quiet := flag.Bool("quiet", false, "Suppress progress and informational output; errors are still printed to stderr")
+	timingFlag := flag.Bool("timing", false, "Print the time spent in each stage (reading, tokenizing, merging, sorting, writing) at the end")
+	fileTimeout := flag.Duration("file-timeout", 0, "Maximum time to process a single file, e.g. 2m; slower files are logged and skipped (0 disables)")
+	dedup := flag.Bool("dedup", false, "Skip files whose content is identical to an already processed file")
+	var excludeGlobs patternList
+	flag.Var(&excludeGlobs, "exclude-glob", "Skip files of -dir whose name or path matches this pattern, e.g. *_draft.txt or archive/* (repeatable or comma-separated)")
+	docFreqOutput := flag.String("doc-freq-output", "", "Output file with the number of documents containing each token (only with -dir)")
+	docDelimiter := flag.String("doc-delimiter", "", "Line separating documents within a file (blank for an empty line); by default each file is one document")
+	minDocFreq := flag.Int("min-doc-freq", 0, "Drop tokens occurring in fewer than this many documents (only with -dir)")
+	tfidfOutput := flag.String("tfidf-output", "", "Output file with tokens ranked by TF-IDF score across the corpus (only with -dir)")
+	indexOutput := flag.String("index-output", "", "Output file with token positions (file:line:byte offset), an inverted index (only with -dir)")
+	indexLimit := flag.Int("index-limit", 100, "Maximum number of positions remembered per token for -index-output")
+	sample := flag.Float64("sample", 0, "Process only this fraction (0.0-1.0) of files for a quick estimate")
+	maxTokens := flag.Int64("max-tokens", 0, "Stop starting new files once this many tokens have been counted; files in progress are finished (0 disables)")
+	maxVocabSize := flag.Int("max-vocab-size", 0, "Bound memory by evicting the rarest tokens whenever the vocabulary grows beyond this many entries; low counts become approximate (0 disables)")
+	sketch := flag.Bool("sketch", false, "Count tokens approximately in a fixed-size count-min sketch and output estimated counts of the most frequent tokens or of -sketch-tokens")
+	sketchWidth := flag.Int("sketch-width", tokenizer.DefaultSketchWidth, "Number of counters per row of the -sketch count-min sketch; estimates exceed true counts by at most e/width of all tokens")
+	sketchDepth := flag.Int("sketch-depth", tokenizer.DefaultSketchDepth, "Number of rows of the -sketch count-min sketch; the error bound holds with probability 1-e^-depth")
+	sketchTop := flag.Int("sketch-top", tokenizer.DefaultSketchTop, "Number of most frequent tokens tracked and output with -sketch")
+	sketchTokens := flag.String("sketch-tokens", "", "File with candidate tokens (one per line) whose estimated counts are output with -sketch instead of the most frequent tokens")
+	seed := flag.Int64("seed", 1, "Random seed for all randomized selection (-sample, -examples); the same seed gives identical output")
+	examples := flag.Int("examples", 0, "Number of example lines to keep per token, sampled uniformly (only with -dir)")
+	examplesOutput := flag.String("examples-output", "examples.txt", "Output file for -examples")
+	normalizeWhitespace := flag.Bool("normalize-whitespace", false, "Strip whitespace, non-breaking and zero-width spaces inside and around tokens")
+	invalidUTF8 := flag.String("invalid-utf8", "keep", "Handling of tokens with invalid UTF-8: keep, drop or strip")
+	splitIdentifiers := flag.Bool("split-identifiers", false, "Split CamelCase and snake_case identifiers into words (getUserName -> get, User, Name)")
+	splitDigits := flag.Bool("split-digits", false, "With -split-identifiers, also split digits from letters (utf8 -> utf, 8)")
+	minCount := flag.Int("min-count", 0, "Drop tokens occurring fewer than this many times")
+	unkToken := flag.String("unk-token", "", "With -min-count, sum the counts of rare tokens into this token (e.g. <UNK>) instead of dropping them")
+	decapSentenceStart := flag.Bool("decap-sentence-start", false, "Lowercase the first word of each sentence, keeping capitals elsewhere (proper nouns)")
+	eosToken := flag.String("eos-token", "", "Count this marker token (e.g. <eos>) once at the end of each sentence")
+	validate := flag.Bool("validate", false, "Check the -input vocabulary file for malformed lines and duplicate tokens; exits with status 1 if problems are found")
+	repair := flag.Bool("repair", false, "With -validate, drop malformed lines, sum duplicate tokens and save the result to -output")
+	duplicates := flag.String("duplicates", "sum", "Handling of tokens repeated within an -input vocabulary file: sum, last, first or error")
+	inputOrder := flag.String("input-order", "token-count", "Field order in input vocabulary files: token-count or count-token")
+	lengthStats := flag.String("length-stats", "", "Output file with the token length distribution: length, number of tokens and total occurrences (/dev/stderr to print it)")
+	mergeWeights := flag.String("merge-weights", "", "Comma-separated weights for -inputs, in the same order; counts of each file are multiplied by its weight before summing")
+	floatCounts := flag.Bool("float-counts", false, "Read and write fractional counts (with -input or -inputs), e.g. for weighted merges")
+	countPrecision := flag.Int("count-precision", 6, "Digits after the decimal point for fractional counts")
+	workersPerFile := flag.Int("workers-per-file", 1, "Split large plain-text files into this many line-aligned parts processed in parallel")
+	errorDir := flag.String("error-dir", tokenizer.DefaultErrorDir, "Directory for the error log and copies of failing files")
+	logFile := flag.String("log-file", "", "Error log file (default: vocab_errors.log in -error-dir)")
+	errorMode := flag.String("error-mode", tokenizer.ErrorModeCopy, "Handling of failing files: copy (log and copy to -error-dir), list (log paths only) or none")
+	ignoreErrors := flag.Bool("ignore-errors", false, "Exit with status 0 even if some files failed to process")
+	inputText := flag.String("input-text", "", "Path to a single document (text, PDF, DOCX, CSV, JSONL, Markdown, .gz, ...) to build a vocabulary from")
+	byteLevel := flag.Bool("byte-level", false, "Count individual bytes of the text as <0xHH> tokens instead of tokenizing (for byte-level BPE)")
+	minPercentile := flag.Float64("min-percentile", 0, "Drop the rarest tokens: those below the count at this percentile (0-100) of sorted counts")
+	punctCategories := flag.String("punct-categories", "", "With -filter-punct, Unicode categories and U+ ranges treated as punctuation, \"!\" excludes (e.g. P,Sm or !Sc,!U+002D)")
+	keepEmoji := flag.Bool("keep-emoji", false, "Count emoji, including ZWJ sequences and skin tones, as separate tokens that -filter-punct keeps")
+	trimPunct := flag.Bool("trim-punct", false, "Strip leading and trailing punctuation from tokens (\"(word),\" -> \"word\"); tokens left empty are dropped")
+	outputJSON := flag.String("output-json", "", "Additional output file with the vocabulary as a JSON object {\"token\": count}")
+	statsOutput := flag.String("stats-output", "", "Output file with vocabulary statistics: types, tokens, hapax, max and mean count")
+	watch := flag.Bool("watch", false, "After building the -dir vocabulary, keep watching the directory and update -output as files are added, changed or removed")
+	watchInterval := flag.Duration("watch-interval", 5*time.Second, "Directory polling interval for -watch; a file is processed once it has not changed for one interval")
+	serveAddr := flag.String("serve", "", "Run an HTTP service on this address (e.g. :8080) that tokenizes and counts posted text")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics (e.g. :9090)")
+	cpuProfile := flag.String("cpuprofile", "", "Write a CPU profile to the file")
+	memProfile := flag.String("memprofile", "", "Write a heap profile to the file on exit")
+	encodingOut := flag.String("encoding-out", "utf-8", "Encoding of the output vocabulary: utf-8, windows-1251 or iso-8859-1")
+	encodingUnmappable := flag.String("encoding-unmappable", "replace", "Characters missing from -encoding-out: replace (with ?), skip (drop the token) or error")
+	dropDigitTokens := flag.Bool("drop-digit-tokens", false, "Drop tokens containing any digit (covid19, 2020s, 42)")
+	normalizeDigits := flag.Bool("normalize-digits", false, "Replace every number token (2019, 3, 1,000) with -num-token before counting, summing their counts")
+	numToken := flag.String("num-token", "<NUM>", "Placeholder token for numbers with -normalize-digits")
+	dropUnderscoreTokens := flag.Bool("drop-underscore-tokens", false, "Drop tokens containing an underscore (snake_case)")
+	dictionary := flag.String("dictionary", "", "File with known words (one per line); only these tokens are counted")
+	splitRunons := flag.Bool("split-runons", false, "Split long unknown tokens run together without spaces (e.g. from PDFs) into known words and re-count the parts")
+	runonMinLength := flag.Int("runon-min-length", tokenizer.DefaultRunonMinLength, "Minimum length in characters of tokens split by -split-runons")
+	runonDict := flag.String("runon-dict", "", "File with known words for -split-runons (one per line); by default, shorter tokens of the vocabulary itself")
+	hashBuckets := flag.Int("hash-buckets", 0, "Count tokens per hash bucket (FNV-1a seeded by -seed, modulo N) instead of storing token strings")
+	hashTokensOutput := flag.String("hash-tokens-output", "", "Output file with a representative token for each hash bucket (with -hash-buckets)")
+	perMillion := flag.Bool("per-million", false, "Write frequencies per million tokens instead of raw counts")
+	compare := flag.Bool("compare", false, "Compare the two -inputs corpora: tokens ranked by the smoothed log-ratio of their normalized frequencies")
+	compareSmoothing := flag.Float64("compare-smoothing", tokenizer.DefaultCompareSmoothing, "Count added to every token when comparing corpora (with -compare)")
+	mergeFoldCase := flag.Bool("merge-fold-case", false, "Merge case variants of tokens from -inputs under the most frequent casing while merging")
+	urlsFile := flag.String("urls", "", "File with http(s) URLs of documents to build the vocabulary from (one per line)")
+	fetchTimeout := flag.Duration("fetch-timeout", tokenizer.DefaultFetchTimeout, "Maximum time to fetch and process a single URL (0 disables)")
+	fetchRetries := flag.Int("fetch-retries", tokenizer.DefaultFetchRetries, "Retries for a URL after network errors, 5xx and 429 responses")
+	s3Prefix := flag.String("s3-prefix", "", "Build the vocabulary from S3 objects under this prefix (s3://bucket/prefix); credentials from the standard AWS environment and config files; requires a build with -tags s3")
 	flag.Parse()
 
-	// Проверка, что указан хотя бы один из флагов: dir, input или inputs
-	if *dirPath == "" && *inputFile == "" && *inputs == "" {
-		fmt.Println("Either -dir, -input, or -inputs must be specified.")
+	// Загрузка значений флагов из файла конфигурации
+	if *configFile != "" {
+		if err := applyConfig(flag.CommandLine, *configFile, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Подавление информационных сообщений; при выводе словаря в stdout сообщения идут в stderr
+	writeStdout := *outputFile == tokenizer.StdoutName
+	if writeStdout {
+		out = os.Stderr
+	}
+	if *quiet {
+		out = io.Discard
+	}
+
+	// Вывод версии
+	if *versionFlag {
+		printVersion()
+		return
+	}
+
+	// Проверка, что указан хотя бы один из флагов: dir, input-text, urls, s3-prefix, input, inputs или serve
+	if *dirPath == "" && *inputText == "" && *urlsFile == "" && *s3Prefix == "" && *inputFile == "" && *inputs == "" && *serveAddr == "" {
+		fmt.Fprintln(os.Stderr, "Either -dir, -input-text, -urls, -s3-prefix, -input, -inputs, or -serve must be specified.")
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if *punctCategories != "" && !*filterPunct {
+		fmt.Fprintln(os.Stderr, "Error: -punct-categories requires -filter-punct")
+		os.Exit(1)
+	}
+
+	if *minPercentile < 0 || *minPercentile >= 100 {
+		fmt.Fprintln(os.Stderr, "Error: -min-percentile must be at least 0 and less than 100")
+		os.Exit(1)
+	}
+
+	if *sample < 0 || *sample > 1 {
+		fmt.Fprintln(os.Stderr, "Error: -sample must be between 0.0 and 1.0")
+		os.Exit(1)
+	}
+
+	// Распознавание сканов выполняется внешними программами, которые проверяются заранее
+	if *ocr {
+		if err := processor.CheckOCR(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Код отделяется при обработке файлов; словарь кода сохраняется вместе с основным
+	codeMode := processor.CodeKeep
+	if *excludeCode {
+		codeMode = processor.CodeExclude
+	}
+	if *separateCode != "" {
+		if *excludeCode || *dirPath == "" && *inputText == "" && *urlsFile == "" && *s3Prefix == "" || *detectLang || *watch || *byteLevel {
+			fmt.Fprintln(os.Stderr, "Error: -separate-code requires -dir, -input-text, -urls or -s3-prefix and is not supported with -exclude-code, -detect-lang, -watch or -byte-level")
+			os.Exit(1)
+		}
+		codeMode = processor.CodeSeparate
+	}
+
+	// Временные файлы создаются только при потоковом объединении
+	if *tempDir != "" && !*streamMerge {
+		fmt.Fprintln(os.Stderr, "Error: -temp-dir requires -stream-merge")
+		os.Exit(1)
+	}
+
+	// Слитные слова разбиваются в готовом словаре целых частот по строкам токенов
+	if *splitRunons && (*serveAddr != "" || *watch || *streamMerge || *floatCounts || *compare || *hashBuckets > 0 || *byteLevel) {
+		fmt.Fprintln(os.Stderr, "Error: -split-runons is not supported with -serve, -watch, -stream-merge, -float-counts, -compare, -hash-buckets or -byte-level")
+		os.Exit(1)
+	}
+	if *runonMinLength < 1 || *runonDict != "" && !*splitRunons {
+		fmt.Fprintln(os.Stderr, "Error: -runon-min-length must be at least 1 and -runon-dict requires -split-runons")
+		os.Exit(1)
+	}
+
+	// Предел проверяется перед запуском обработки каждого файла
+	if *maxTokens < 0 || *maxTokens > 0 && (*dirPath == "" && *urlsFile == "" && *s3Prefix == "" || *watch) {
+		fmt.Fprintln(os.Stderr, "Error: -max-tokens must not be negative and requires -dir, -urls or -s3-prefix; it is not supported with -watch")
+		os.Exit(1)
+	}
+	// Редкие токены вытесняются из общего словаря при его построении из файлов
+	if *maxVocabSize < 0 || *maxVocabSize > 0 && (*dirPath == "" && *urlsFile == "" && *s3Prefix == "" || *watch) {
+		fmt.Fprintln(os.Stderr, "Error: -max-vocab-size must not be negative and requires -dir, -urls or -s3-prefix; it is not supported with -watch")
+		os.Exit(1)
+	}
+	// Токен-заместитель записывается в словарь, поэтому не может быть пустым или содержать пробелы
+	if *normalizeDigits && (*numToken == "" || strings.ContainsAny(*numToken, " \t")) {
+		fmt.Fprintln(os.Stderr, "Error: -num-token must be a non-empty token without spaces")
+		os.Exit(1)
+	}
+	numberPlaceholder := ""
+	if *normalizeDigits {
+		numberPlaceholder = *numToken
+	}
+
+	// Шаблоны проверяются при чтении директории
+	if len(excludeGlobs) > 0 && *dirPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: -exclude-glob requires -dir")
+		os.Exit(1)
+	}
+
+	// Части речи определяются по словарю при подсчете
+	if (*posFilter != "" || *posTags) != (*posDict != "") {
+		fmt.Fprintln(os.Stderr, "Error: -pos-filter and -pos-tags require -pos-dict, and -pos-dict requires one of them")
+		os.Exit(1)
+	}
+	// Порядок первого появления определен только для одного документа, читаемого последовательно
+	if *sortType == tokenizer.SortFirstSeen && (*inputText == "" || *dirPath != "" || *serveAddr != "" || *byteLevel) {
+		fmt.Fprintln(os.Stderr, "Error: -sort firstseen requires -input-text and is not supported with -dir, -serve or -byte-level")
+		os.Exit(1)
+	}
+	// Язык определяется по служебным словам, а не по хешам или байтам
+	if *detectLang && (*dirPath == "" || *hashBuckets > 0 || *byteLevel || *langConfidence < 0 || *langConfidence > 1) {
+		fmt.Fprintln(os.Stderr, "Error: -detect-lang requires -dir and is not supported with -hash-buckets or -byte-level; -lang-confidence must be between 0 and 1")
+		os.Exit(1)
+	}
+	// Sketch заменяет общий словарь при построении из файлов
+	if *sketch && (*dirPath == "" && *urlsFile == "" && *s3Prefix == "" || *watch || *detectLang || *maxVocabSize > 0 || *hashBuckets > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -sketch requires -dir, -urls or -s3-prefix and is not supported with -watch, -detect-lang, -max-vocab-size or -hash-buckets")
+		os.Exit(1)
+	}
+	if *sketchWidth < 1 || *sketchDepth < 1 || *sketchTop < 1 || *sketchTokens != "" && !*sketch {
+		fmt.Fprintln(os.Stderr, "Error: -sketch-width, -sketch-depth and -sketch-top must be at least 1 and -sketch-tokens requires -sketch")
+		os.Exit(1)
+	}
+
+	if *floatCounts && (*dirPath != "" || *inputText != "" || *streamMerge || *inputFile == "" && *inputs == "") {
+		fmt.Fprintln(os.Stderr, "Error: -float-counts requires -input or -inputs and is not supported with -dir or -stream-merge")
+		os.Exit(1)
+	}
+	// Отбор редких токенов работает с целыми частотами; при сравнении -min-count применяется
+	// к суммарной частоте, а при потоковом объединении — к каждой записываемой строке
+	if *floatCounts && (*minCount > 0 || *minPercentile > 0 || *unkToken != "") {
+		fmt.Fprintln(os.Stderr, "Error: -min-count, -min-percentile and -unk-token are not supported with -float-counts")
+		os.Exit(1)
+	}
+	if (*compare || *streamMerge) && (*minPercentile > 0 || *unkToken != "") {
+		fmt.Fprintln(os.Stderr, "Error: -min-percentile and -unk-token are not supported with -compare or -stream-merge")
+		os.Exit(1)
+	}
+
+	if *countPrecision < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -count-precision must be at least 1")
+		os.Exit(1)
+	}
+
+	var weights []float64
+	if *mergeWeights != "" {
+		if *inputs == "" || *streamMerge {
+			fmt.Fprintln(os.Stderr, "Error: -merge-weights requires -inputs and is not supported with -stream-merge")
+			os.Exit(1)
+		}
+		var err error
+		if weights, err = parseWeights(*mergeWeights, len(strings.Split(*inputs, ","))); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Документная частота собирается только при обработке файлов
+	if *minDocFreq > 1 && *dirPath == "" && *inputText == "" && *urlsFile == "" && *s3Prefix == "" {
+		fmt.Fprintln(os.Stderr, "Error: -min-doc-freq requires -dir, -input-text, -urls or -s3-prefix")
+		os.Exit(1)
+	}
+	if *tfidfOutput != "" && *dirPath == "" && *inputText == "" && *urlsFile == "" && *s3Prefix == "" {
+		fmt.Fprintln(os.Stderr, "Error: -tfidf-output requires -dir, -input-text, -urls or -s3-prefix")
+		os.Exit(1)
+	}
+
+	// В режиме наблюдения вклад файла вычитается при его изменении, что невозможно
+	// для статистики, собираемой по всем файлам сразу
+	if *watch && (*dirPath == "" || *detectLang || *dedup || *docFreqOutput != "" || *minDocFreq > 1 || *tfidfOutput != "" || *indexOutput != "" || *examples > 0 || *provenance != "") {
+		fmt.Fprintln(os.Stderr, "Error: -watch requires -dir and is not supported with -detect-lang, -dedup, document frequencies, -index-output, -examples or -provenance")
+		os.Exit(1)
+	}
+	if *watch && *watchInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -watch-interval must be positive")
+		os.Exit(1)
+	}
+
+	// Побайтовый подсчет не сохраняет строки и позиции токенов
+	if *byteLevel && (*dirPath == "" && *inputText == "" && *urlsFile == "" && *s3Prefix == "" || *docFreqOutput != "" || *minDocFreq > 1 || *tfidfOutput != "" || *indexOutput != "" || *examples > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -byte-level requires -dir, -input-text, -urls or -s3-prefix and is not supported with document frequencies, -index-output or -examples")
+		os.Exit(1)
+	}
+
+	// Хешируются токены, а не байты; представители корзин есть только при хешировании
+	if *hashBuckets < 0 || *hashBuckets > 0 && *byteLevel {
+		fmt.Fprintln(os.Stderr, "Error: -hash-buckets must not be negative and is not supported with -byte-level")
+		os.Exit(1)
+	}
+	if *hashTokensOutput != "" && *hashBuckets == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -hash-tokens-output requires -hash-buckets")
+		os.Exit(1)
+	}
+
+	// Сравниваются ровно два словаря целых частот
+	if *compare && (*inputs == "" || len(strings.Split(*inputs, ",")) != 2 || *streamMerge || *floatCounts) {
+		fmt.Fprintln(os.Stderr, "Error: -compare requires exactly two -inputs files and is not supported with -stream-merge or -float-counts")
+		os.Exit(1)
+	}
+	if *compareSmoothing <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -compare-smoothing must be positive")
+		os.Exit(1)
+	}
+
+	// Потоковое объединение записывает словарь до подсчета общего числа токенов
+	if *perMillion && (*streamMerge || *floatCounts) {
+		fmt.Fprintln(os.Stderr, "Error: -per-million is not supported with -stream-merge or -float-counts")
+		os.Exit(1)
+	}
+
+	// Дополнительные выходные файлы записываются только из общего словаря целых частот
+	if (*outputJSON != "" || *statsOutput != "") && (*serveAddr != "" || *streamMerge || *compare || *detectLang || *floatCounts || *watch || *validate) {
+		fmt.Fprintln(os.Stderr, "Error: -output-json and -stats-output are not supported with -serve, -stream-merge, -compare, -detect-lang, -float-counts, -watch or -validate")
+		os.Exit(1)
+	}
+
 	// Если maxGoroutines не указан, используем количество процессоров
 	if *maxGoroutines <= 0 {
 		*maxGoroutines = runtime.NumCPU()
-		fmt.Printf("Using %d goroutines (number of CPUs)\n", *maxGoroutines)
+		fmt.Fprintf(out, "Using %d goroutines (number of CPUs)\n", *maxGoroutines)
 	}
 
 	// Включение pprof
 	if *pprofFlag {
 		go func() {
-			fmt.Println("Starting pprof server on http://localhost:6060")
-			if err := http.ListenAndServe("localhost:6060", nil); err != nil {
-				fmt.Printf("Error starting pprof server: %v\n", err)
+			fmt.Fprintf(out, "Starting pprof server on http://%s\n", pprofAddr)
+			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+				fmt.Fprintf(os.Stderr, "Error starting pprof server: %v\n", err)
 			}
 		}()
 		time.Sleep(1 * time.Second) // Даем время для запуска сервера
 	}
 
+	// Запись профилей в файлы
+	if err := startProfiles(*cpuProfile, *memProfile); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer stopProfiles()
+
+	// Загрузка из S3: запросы подписываются ключами из стандартных настроек AWS
+	var httpClient *http.Client
+	var s3Objects *s3Input
+	if *s3Prefix != "" {
+		var err error
+		if s3Objects, err = newS3Input(*s3Prefix); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		httpClient = s3Objects.httpClient()
+	}
+
 	// Создание токенизатора
-	tokenizer, err := tokenizer.NewTokenizer(*lowercase, *filterPunct)
+	tokenizer, err := tokenizer.NewTokenizer(tokenizer.Options{
+		Lowercase:            *lowercase,
+		FilterPunct:          *filterPunct,
+		TrimPunct:            *trimPunct,
+		PunctCategories:      *punctCategories,
+		ProgressInterval:     *progressInterval,
+		WhitelistFile:        *whitelist,
+		BlacklistFile:        *blacklist,
+		DictionaryFile:       *dictionary,
+		SplitRunons:          *splitRunons,
+		RunonMinLength:       *runonMinLength,
+		RunonDictFile:        *runonDict,
+		Lemmatize:            *lemmatize,
+		LemmaDictFile:        *lemmaDict,
+		Stem:                 *stem,
+		StemLang:             *stemLang,
+		PosDictFile:          *posDict,
+		PosFilter:            *posFilter,
+		PosTags:              *posTags,
+		SplitHyphens:         *splitHyphens,
+		KeepApostrophes:      *keepApostrophes,
+		KeepEmoji:            *keepEmoji,
+		DropDigitTokens:      *dropDigitTokens,
+		NumToken:             numberPlaceholder,
+		DropUnderscoreTokens: *dropUnderscoreTokens,
+		FoldCase:             *foldCase,
+		Format:               *format,
+		ThousandsSep:         *thousandsSep,
+		HumanCounts:          *humanCounts,
+		OutputEncoding:       *encodingOut,
+		Unmappable:           *encodingUnmappable,
+		LangConfidence:       *langConfidence,
+		Script:               *script,
+		ScriptStrict:         *scriptStrict,
+		Provenance:           *provenance != "",
+		ProvenanceLimit:      *provenanceLimit,
+		ProvenanceAbs:        *provenanceAbs,
+		MergeChunkSize:       *mergeChunkSize,
+		TempDir:              *tempDir,
+		Duplicates:           *duplicates,
+		InputOrder:           *inputOrder,
+		MergeWeights:         weights,
+		MergeFoldCase:        *mergeFoldCase,
+		CountPrecision:       *countPrecision,
+		FileTimeout:          *fileTimeout,
+		MaxGoroutines:        *maxGoroutines,
+		WorkersPerFile:       *workersPerFile,
+		FetchTimeout:         *fetchTimeout,
+		FetchRetries:         *fetchRetries,
+		HTTPClient:           httpClient,
+		ErrorDir:             *errorDir,
+		LogFile:              *logFile,
+		ErrorMode:            *errorMode,
+		Dedup:                *dedup,
+		DocFreq:              *docFreqOutput != "" || *minDocFreq > 1 || *tfidfOutput != "",
+		DocDelimiter:         *docDelimiter,
+		MinDocFreq:           *minDocFreq,
+		MinCount:             *minCount,
+		MinPercentile:        *minPercentile,
+		UnkToken:             *unkToken,
+		Index:                *indexOutput != "",
+		IndexLimit:           *indexLimit,
+		Sample:               *sample,
+		MaxTokens:            *maxTokens,
+		MaxVocabSize:         *maxVocabSize,
+		ExcludeGlobs:         excludeGlobs,
+		Sketch:               *sketch,
+		FirstSeen:            *sortType == tokenizer.SortFirstSeen,
+		SketchWidth:          *sketchWidth,
+		SketchDepth:          *sketchDepth,
+		SketchTop:            *sketchTop,
+		SketchTokensFile:     *sketchTokens,
+		Seed:                 *seed,
+		Examples:             *examples,
+		HashBuckets:          *hashBuckets,
+		HashTokens:           *hashTokensOutput != "",
+		NormalizeWhitespace:  *normalizeWhitespace,
+		InvalidUTF8:          *invalidUTF8,
+		SplitIdentifiers:     *splitIdentifiers,
+		ByteLevel:            *byteLevel,
+		SplitDigits:          *splitDigits,
+		DecapSentenceStart:   *decapSentenceStart,
+		EOSToken:             *eosToken,
+		Quiet:                *quiet,
+		Timing:               *timingFlag,
+		Stderr:               writeStdout,
+		Processor: processor.Options{
+			CSVColumn:        *csvColumn,
+			JSONField:        *jsonField,
+			Code:             codeMode,
+			MarkdownKeepURLs: *markdownKeepURLs,
+			OCR:              *ocr,
+			OCRLang:          *ocrLang,
+		},
+	})
 	if err != nil {
-		fmt.Println("Error:", err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 	defer tokenizer.Close()
+	defer tokenizer.PrintTiming()
 
-	// Сценарий 1: Создание нового словаря из файлов в директории
-	if *dirPath != "" {
-		err = tokenizer.ProcessFiles(*dirPath, *maxGoroutines, *outputFile, *sortType)
+	// Публикация метрик обработки
+	if *metricsAddr != "" {
+		serveMetrics(tokenizer, *metricsAddr, *pprofFlag)
+	}
+
+	var vocab map[string]int
+	var savedMessage string
+
+	switch {
+	// Сценарий 4: HTTP-сервис токенизации до остановки (Ctrl+C, SIGTERM)
+	case *serveAddr != "":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := serve(ctx, tokenizer, *serveAddr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+
+	// Сценарий 3в: Сравнение двух корпусов
+	case *inputs != "" && *compare:
+		var corpora [2]map[string]int
+		for i, filePath := range strings.Split(*inputs, ",") {
+			loadedVocab, err := tokenizer.LoadVocabulary(filePath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error loading vocabulary:", err)
+				os.Exit(1)
+			}
+			corpora[i] = tokenizer.ProcessVocabulary(loadedVocab)
+		}
+		if err := tokenizer.SaveComparison(corpora[0], corpora[1], *compareSmoothing, *outputFile); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving comparison:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Comparison saved to", *outputFile)
+		return
+
+	// Сценарий 3а: Потоковое объединение словарей с записью сразу в выходной файл
+	case *inputs != "" && *streamMerge:
+		if *sortType == "freq" {
+			fmt.Fprintln(os.Stderr, "Error: -sort=freq is not supported with -stream-merge (output is sorted by token)")
+			os.Exit(1)
+		}
+		if err := tokenizer.StreamMergeVocabularies(strings.Split(*inputs, ","), *outputFile); err != nil {
+			fmt.Fprintln(os.Stderr, "Error merging vocabularies:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Merged vocabulary saved to", *outputFile)
+		return
+
+	// Сценарий 1а: Отдельные словари для каждого языка
+	case *dirPath != "" && *detectLang:
+		vocabs, err := tokenizer.BuildLanguageVocabularies(*dirPath, *maxGoroutines)
 		if err != nil {
-			fmt.Println("Error:", err)
+			fmt.Fprintln(os.Stderr, "Error:", err)
 			os.Exit(1)
 		}
-		fmt.Println("Vocabulary saved to", *outputFile)
+		for lang, langVocab := range vocabs {
+			if *splitRunons {
+				langVocab = tokenizer.SplitRunons(langVocab)
+			}
+			// Частоты на миллион считаются от всех токенов языка до фильтрации
+			filtered := tokenizer.FilterVocabulary(langVocab)
+			langOutput := langOutputFile(*outputFile, lang)
+			if err := saveCounts(tokenizer, filtered, langVocab, *perMillion, langOutput, *sortType); err != nil {
+				fmt.Fprintln(os.Stderr, "Error saving vocabulary:", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(out, "Vocabulary for language %s (%d tokens) saved to %s\n", lang, len(filtered), langOutput)
+		}
+		exitOnFailures(tokenizer, *ignoreErrors)
 		return
-	}
 
-	// Сценарий 2: Обработка готового словаря
-	if *inputFile != "" {
-		vocab, err := tokenizer.LoadVocabulary(*inputFile)
+	// Сценарий 2а: Проверка и исправление файла словаря
+	case *inputFile != "" && *validate:
+		problems, repaired, err := tokenizer.ValidateVocabulary(*inputFile)
 		if err != nil {
-			fmt.Println("Error loading vocabulary:", err)
+			fmt.Fprintln(os.Stderr, "Error validating vocabulary:", err)
+			os.Exit(1)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *inputFile, problem)
+		}
+		fmt.Fprintf(out, "Found %d problems in %s\n", len(problems), *inputFile)
+		if !*repair {
+			if len(problems) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		if err := tokenizer.SaveVocabulary(repaired, *outputFile, *sortType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving vocabulary:", err)
 			os.Exit(1)
 		}
+		fmt.Fprintln(out, "Repaired vocabulary saved to", *outputFile)
+		return
 
-		processedVocab := tokenizer.ProcessVocabulary(vocab)
-		err = tokenizer.SaveVocabulary(processedVocab, *outputFile, *sortType)
+	// Сценарий 3б: Словари с дробными частотами
+	case *floatCounts:
+		var floatVocab map[string]float64
+		if *inputs != "" {
+			floatVocab, err = tokenizer.MergeFloatVocabularies(strings.Split(*inputs, ","))
+		} else {
+			floatVocab, err = tokenizer.LoadFloatVocabulary(*inputFile)
+		}
 		if err != nil {
-			fmt.Println("Error saving vocabulary:", err)
+			fmt.Fprintln(os.Stderr, "Error loading vocabulary:", err)
 			os.Exit(1)
 		}
-		fmt.Println("Processed vocabulary saved to", *outputFile)
+		floatVocab = tokenizer.ProcessFloatVocabulary(floatVocab)
+		if err := tokenizer.SaveFloatVocabulary(floatVocab, *outputFile, *sortType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving vocabulary:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Vocabulary with fractional counts saved to", *outputFile)
 		return
-	}
+
+	// Сценарий 1в: Наблюдение за директорией с обновлением словаря до остановки (Ctrl+C, SIGTERM)
+	case *dirPath != "" && *watch:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := tokenizer.WatchDirectory(ctx, *dirPath, *maxGoroutines, *watchInterval, func(vocab map[string]int) error {
+			return saveCounts(tokenizer, tokenizer.FilterVocabulary(vocab), vocab, *perMillion, *outputFile, *sortType)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Stopped watching", *dirPath)
+		return
+
+	// Сценарий 1: Создание нового словаря из файлов в директории
+	case *dirPath != "":
+		vocab, err = tokenizer.BuildVocabulary(*dirPath, *maxGoroutines)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		savedMessage = "Vocabulary saved to"
+
+	// Сценарий 1б: Создание словаря из одного документа
+	case *inputText != "":
+		vocab, err = tokenizer.BuildFileVocabulary(*inputText)
+		if err != nil {
+			// Сообщение об ошибке уже начинается с "Error ..."
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		savedMessage = "Vocabulary saved to"
+
+	// Сценарий 1г: Создание словаря из документов, загружаемых по URL
+	case *urlsFile != "":
+		urls, err := readURLList(*urlsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		vocab, err = tokenizer.BuildURLVocabulary(urls, *maxGoroutines)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		savedMessage = "Vocabulary saved to"
+
+	// Сценарий 1д: Создание словаря из объектов S3
+	case *s3Prefix != "":
+		urls, err := s3Objects.list()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(out, "Found %d objects under %s\n", len(urls), *s3Prefix)
+		vocab, err = tokenizer.BuildURLVocabulary(urls, *maxGoroutines)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		savedMessage = "Vocabulary saved to"
+
+	// Сценарий 2: Обработка готового словаря
+	case *inputFile != "":
+		loadedVocab, err := tokenizer.LoadVocabulary(*inputFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading vocabulary:", err)
+			os.Exit(1)
+		}
+		vocab = tokenizer.ProcessVocabulary(loadedVocab)
+		savedMessage = "Processed vocabulary saved to"
 
 	// Сценарий 3: Объединение словарей
-	if *inputs != "" {
+	case *inputs != "":
 		inputFiles := strings.Split(*inputs, ",")
-		vocab, err := tokenizer.MergeVocabularies(inputFiles)
+		mergedVocab, err := tokenizer.MergeVocabularies(inputFiles)
 		if err != nil {
-			fmt.Println("Error merging vocabularies:", err)
+			fmt.Fprintln(os.Stderr, "Error merging vocabularies:", err)
+			os.Exit(1)
+		}
+		vocab = tokenizer.ProcessVocabulary(mergedVocab)
+		savedMessage = "Merged vocabulary saved to"
+	}
+
+	// Разбиение слитно записанных слов до фильтрации, чтобы частоты частей учитывались в -min-count
+	if *splitRunons {
+		vocab = tokenizer.SplitRunons(vocab)
+	}
+
+	// Фильтрация собранного словаря; частоты на миллион считаются от всех токенов до фильтрации
+	unfiltered := vocab
+	vocab = tokenizer.FilterVocabulary(vocab)
+
+	// Документная частота токенов
+	if *docFreqOutput != "" {
+		fmt.Fprintf(out, "Documents processed: %d\n", tokenizer.Documents())
+		if err := tokenizer.SaveVocabulary(tokenizer.DocumentFrequencies(vocab), *docFreqOutput, *sortType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving document frequencies:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Document frequencies saved to", *docFreqOutput)
+	}
+
+	// Оценки TF-IDF
+	if *tfidfOutput != "" {
+		if err := tokenizer.SaveTFIDF(vocab, *tfidfOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving TF-IDF scores:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "TF-IDF scores saved to", *tfidfOutput)
+	}
+
+	// Обратный индекс
+	if *indexOutput != "" {
+		if err := tokenizer.SaveIndex(vocab, *indexOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving index:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Token index saved to", *indexOutput)
+	}
+
+	// Примеры строк
+	if *examples > 0 {
+		if err := tokenizer.SaveExamples(vocab, *examplesOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving examples:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Token examples saved to", *examplesOutput)
+	}
+
+	// Представители корзин хеширования
+	if *hashTokensOutput != "" {
+		if err := tokenizer.SaveHashTokens(vocab, *hashTokensOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving hash bucket tokens:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Hash bucket tokens saved to", *hashTokensOutput)
+	}
+
+	// Файлы-источники токенов
+	if *provenance != "" {
+		if err := tokenizer.SaveProvenance(vocab, *provenance); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving provenance:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Token provenance saved to", *provenance)
+	}
+
+	// Распределение длин токенов
+	if *lengthStats != "" {
+		if err := tokenizer.SaveLengthStats(vocab, *lengthStats); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving token length statistics:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Token length statistics saved to", *lengthStats)
+	}
+
+	// Сводная статистика словаря
+	if *statsOutput != "" {
+		if err := tokenizer.SaveStats(vocab, *statsOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving vocabulary statistics:", err)
 			os.Exit(1)
 		}
+		fmt.Fprintln(out, "Vocabulary statistics saved to", *statsOutput)
+	}
 
-		processedVocab := tokenizer.ProcessVocabulary(vocab)
-		err = tokenizer.SaveVocabulary(processedVocab, *outputFile, *sortType)
+	// Словарь в формате JSON
+	if *outputJSON != "" {
+		if err := tokenizer.SaveVocabularyJSON(vocab, *outputJSON, *sortType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving JSON vocabulary:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "JSON vocabulary saved to", *outputJSON)
+	}
+
+	// Распределение ранг-частота
+	if *zipfOutput != "" {
+		exponent, err := tokenizer.SaveZipf(vocab, *zipfOutput)
 		if err != nil {
-			fmt.Println("Error saving vocabulary:", err)
+			fmt.Fprintln(os.Stderr, "Error saving rank-frequency distribution:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(out, "Estimated Zipf exponent: %.4f\n", exponent)
+		fmt.Fprintln(out, "Rank-frequency distribution saved to", *zipfOutput)
+	}
+
+	// Обучение BPE на частотах слов
+	if *bpeMerges > 0 {
+		if err := trainBPE(vocab, *bpeMerges, *bpeDir); err != nil {
+			fmt.Fprintln(os.Stderr, "Error training BPE:", err)
 			os.Exit(1)
 		}
-		fmt.Println("Merged vocabulary saved to", *outputFile)
+	}
+
+	// Построение словаря WordPiece
+	if *wordpieceSize > 0 {
+		if err := trainWordPiece(vocab, *wordpieceSize, *wordpieceOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error generating WordPiece vocabulary:", err)
+			os.Exit(1)
+		}
+	}
+
+	err = saveCounts(tokenizer, vocab, unfiltered, *perMillion, *outputFile, *sortType)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error saving vocabulary:", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(out, savedMessage, *outputFile)
+
+	// Словарь кода, отделенного от текста
+	if *separateCode != "" {
+		codeVocab := tokenizer.CodeVocabulary()
+		if err := saveCounts(tokenizer, codeVocab, codeVocab, *perMillion, *separateCode, *sortType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving code vocabulary:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "Code vocabulary saved to", *separateCode)
+	}
+	exitOnFailures(tokenizer, *ignoreErrors)
+}
+
+// Сохранение словаря; при perMillion частоты пересчитываются на миллион токенов корпуса,
+// общее число которых берется из словаря corpus до отбора редких токенов
+func saveCounts(t *tokenizer.Tokenizer, vocab, corpus map[string]int, perMillion bool, outputFile, sortType string) error {
+	if perMillion {
+		return t.SaveFloatVocabulary(tokenizer.PerMillion(vocab, tokenizer.TotalCount(corpus)), outputFile, sortType)
+	}
+	return t.SaveVocabulary(vocab, outputFile, sortType)
+}
+
+// Завершение с кодом 1, если часть файлов не удалось обработать: словарь уже сохранен,
+// но неполон. С -ignore-errors такие файлы только перечисляются в итоговой сводке.
+func exitOnFailures(t *tokenizer.Tokenizer, ignore bool) {
+	if ignore || len(t.Failures()) == 0 {
+		return
+	}
+	t.PrintTiming()
+	t.Close()
+	stopProfiles()
+	os.Exit(1)
+}
+
+// Чтение списка URL (по одному в строке); пустые строки и строки, начинающиеся с #, пропускаются
+func readURLList(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading URL list: %v", err)
+	}
+	var urls []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, "http://") && !strings.HasPrefix(line, "https://") {
+			return nil, fmt.Errorf("line %d of %s is not an http(s) URL: %q", i+1, filePath, line)
+		}
+		urls = append(urls, line)
+	}
+	return urls, nil
+}
+
+// Разбор весов словарей "2,1,0.5"; число весов должно совпадать с числом файлов
+func parseWeights(s string, files int) ([]float64, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != files {
+		return nil, fmt.Errorf("-merge-weights has %d weights for %d input files", len(fields), files)
+	}
+	weights := make([]float64, len(fields))
+	for i, field := range fields {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return nil, fmt.Errorf("invalid merge weight %q", field)
+		}
+		weights[i] = weight
+	}
+	return weights, nil
+}
+
+// Повторяемый флаг со списком шаблонов: значения накапливаются, а значение через запятую
+// (в том числе список из файла конфигурации) разбивается на отдельные шаблоны
+type patternList []string
+
+func (l *patternList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *patternList) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*l = append(*l, pattern)
+		}
+	}
+	return nil
+}
+
+// Имя файла словаря для языка: vocab.txt -> vocab.ru.txt
+func langOutputFile(outputFile, lang string) string {
+	ext := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, ext) + "." + lang + ext
+}
+
+// Обучение BPE и сохранение merges.txt и vocab.json
+func trainBPE(vocab map[string]int, merges int, dir string) error {
+	fmt.Fprintf(out, "Training BPE with %d merges...\n", merges)
+	startTime := time.Now()
+	model := bpe.Train(vocab, merges)
+	fmt.Fprintf(out, "BPE training completed in %v (%d merges learned).\n", time.Since(startTime), len(model.Merges))
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating BPE directory: %v", err)
+	}
+	if err := model.WriteMerges(filepath.Join(dir, "merges.txt")); err != nil {
+		return err
+	}
+	if err := model.WriteVocab(filepath.Join(dir, "vocab.json")); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "BPE model saved to", dir)
+	return nil
+}
+
+// Построение словаря WordPiece и сохранение в формате vocab.txt
+func trainWordPiece(vocab map[string]int, size int, outputFile string) error {
+	fmt.Fprintf(out, "Generating WordPiece vocabulary of size %d...\n", size)
+	startTime := time.Now()
+	model := bpe.TrainWordPiece(vocab, size)
+	fmt.Fprintf(out, "WordPiece generation completed in %v (%d tokens).\n", time.Since(startTime), len(model.Vocab))
+
+	if err := model.WriteVocab(outputFile); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "WordPiece vocabulary saved to", outputFile)
+	return nil
+}
+
+// Вывод версии модуля, ревизии VCS и версии Go
+func printVersion() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		fmt.Println("vocab (build information unavailable)")
+		fmt.Println("go:", runtime.Version())
 		return
 	}
+
+	fmt.Println("vocab", info.Main.Version)
+	settings := make(map[string]string)
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision, ok := settings["vcs.revision"]; ok {
+		if settings["vcs.modified"] == "true" {
+			revision += " (modified)"
+		}
+		fmt.Println("revision:", revision)
+	}
+	if buildTime, ok := settings["vcs.time"]; ok {
+		fmt.Println("commit time:", buildTime)
+	}
+	fmt.Println("go:", info.GoVersion)
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/terratensor/vocab/internal/tokenizer"
+)
+
+// Адрес сервера pprof (-pprof)
+const pprofAddr = "localhost:6060"
+
+// Публикация метрик обработки в формате Prometheus по адресу /metrics.
+// Обработчик регистрируется в http.DefaultServeMux, поэтому метрики доступны и на сервере
+// pprof; на addr запускается отдельный сервер, если это не адрес уже запущенного сервера pprof.
+func serveMetrics(t *tokenizer.Tokenizer, addr string, pprofRunning bool) {
+	http.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		t.WriteMetrics(w)
+	})
+	if pprofRunning && addr == pprofAddr {
+		return
+	}
+	go func() {
+		fmt.Fprintf(out, "Serving metrics on http://%s/metrics\n", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting metrics server: %v\n", err)
+		}
+	}()
+}
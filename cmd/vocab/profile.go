@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Завершение записи профилей (-cpuprofile, -memprofile); вызывается перед выходом из программы
+var stopProfiles = func() {}
+
+// Запуск записи профиля CPU в cpuFile; профиль памяти записывается в memFile при вызове stopProfiles.
+// В отличие от сервера pprof, профили пишутся в файлы и подходят для коротких запусков.
+func startProfiles(cpuFile, memFile string) error {
+	var cpu *os.File
+	if cpuFile != "" {
+		file, err := os.Create(cpuFile)
+		if err != nil {
+			return fmt.Errorf("error creating CPU profile: %v", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("error starting CPU profile: %v", err)
+		}
+		cpu = file
+	}
+
+	stopped := false
+	stopProfiles = func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing CPU profile:", err)
+			} else {
+				fmt.Fprintln(out, "CPU profile saved to", cpuFile)
+			}
+		}
+		if memFile != "" {
+			if err := writeHeapProfile(memFile); err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing memory profile:", err)
+			} else {
+				fmt.Fprintln(out, "Memory profile saved to", memFile)
+			}
+		}
+	}
+	return nil
+}
+
+// Запись профиля кучи; сборка мусора перед записью дает актуальную статистику выделений
+func writeHeapProfile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
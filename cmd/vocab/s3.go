@@ -0,0 +1,48 @@
+//go:build s3
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/terratensor/vocab/internal/s3"
+)
+
+// s3Input — объекты S3 по адресу s3://bucket/prefix
+type s3Input struct {
+	client         *s3.Client
+	bucket, prefix string
+}
+
+// Подключение к S3 по стандартным настройкам AWS и проверка адреса
+func newS3Input(uri string) (*s3Input, error) {
+	bucket, prefix, err := s3.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s3.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring S3 client: %v", err)
+	}
+	return &s3Input{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+// Клиент, подписывающий запросы к объектам
+func (in *s3Input) httpClient() *http.Client {
+	return in.client.HTTPClient
+}
+
+// Адреса объектов с префиксом для загрузки клиентом httpClient
+func (in *s3Input) list() ([]string, error) {
+	keys, err := in.client.ListObjects(context.Background(), in.bucket, in.prefix)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(keys))
+	for i, key := range keys {
+		urls[i] = in.client.ObjectURL(in.bucket, key)
+	}
+	return urls, nil
+}
@@ -0,0 +1,23 @@
+//go:build !s3
+
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Без тега s3 поддержка S3 не собирается, и -s3-prefix завершается ошибкой
+type s3Input struct{}
+
+func newS3Input(uri string) (*s3Input, error) {
+	return nil, errors.New("-s3-prefix is not supported by this build (rebuild with -tags s3)")
+}
+
+func (in *s3Input) httpClient() *http.Client {
+	return nil
+}
+
+func (in *s3Input) list() ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terratensor/vocab/internal/tokenizer"
+)
+
+// Максимальный размер тела запроса к сервису
+const maxRequestBody = 32 << 20
+
+// Время на завершение обрабатываемых запросов при остановке сервиса
+const shutdownTimeout = 10 * time.Second
+
+// server — HTTP-сервис токенизации и подсчета частот (-serve)
+type server struct {
+	tokenizer *tokenizer.Tokenizer
+
+	mu    sync.Mutex
+	vocab map[string]int // Частоты, накопленные запросами /count?accumulate=true
+}
+
+func newServer(t *tokenizer.Tokenizer) *server {
+	return &server{tokenizer: t, vocab: make(map[string]int)}
+}
+
+// Маршруты сервиса
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /tokenize", s.handleTokenize)
+	mux.HandleFunc("POST /count", s.handleCount)
+	mux.HandleFunc("GET /vocabulary", s.handleVocabulary)
+	return mux
+}
+
+// Запуск сервиса на addr до отмены ctx с корректным завершением обрабатываемых запросов
+func serve(ctx context.Context, t *tokenizer.Tokenizer, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: newServer(t).handler()}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintln(out, "Serving on", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintln(out, "Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// GET /health: {"status": "ok"}
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// POST /tokenize: {"tokens": [...]} — токены текста в порядке следования
+func (s *server) handleTokenize(w http.ResponseWriter, r *http.Request) {
+	text, err := requestText(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	tokens := s.tokenizer.TokenizeText(text)
+	if tokens == nil {
+		tokens = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"tokens": tokens})
+}
+
+// POST /count: {"counts": {...}} — частоты токенов текста. С параметром accumulate=true
+// частоты добавляются к словарю сервиса, который возвращает GET /vocabulary.
+func (s *server) handleCount(w http.ResponseWriter, r *http.Request) {
+	text, err := requestText(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	counts := make(map[string]int)
+	for _, token := range s.tokenizer.TokenizeText(text) {
+		counts[token]++
+	}
+
+	if r.URL.Query().Get("accumulate") == "true" {
+		s.mu.Lock()
+		for token, count := range counts {
+			s.vocab[token] += count
+		}
+		s.mu.Unlock()
+	}
+	writeJSON(w, http.StatusOK, map[string]map[string]int{"counts": counts})
+}
+
+// GET /vocabulary: {"counts": {...}} — частоты, накопленные запросами /count?accumulate=true
+func (s *server) handleVocabulary(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	counts := make(map[string]int, len(s.vocab))
+	for token, count := range s.vocab {
+		counts[token] = count
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]map[string]int{"counts": counts})
+}
+
+// Текст запроса: тело целиком или поле "text", если тело — JSON (Content-Type: application/json)
+func requestText(w http.ResponseWriter, r *http.Request) (string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err != nil {
+		return "", fmt.Errorf("error reading request body: %v", err)
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return string(body), nil
+	}
+
+	var request struct {
+		Text *string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", fmt.Errorf("invalid JSON request: %v", err)
+	}
+	if request.Text == nil {
+		return "", errors.New(`JSON request must have a "text" field`)
+	}
+	return *request.Text, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/terratensor/vocab/internal/tokenizer"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	tok, err := tokenizer.NewTokenizer(tokenizer.Options{ErrorMode: tokenizer.ErrorModeNone, Quiet: true})
+	if err != nil {
+		t.Fatalf("NewTokenizer: %v", err)
+	}
+	t.Cleanup(tok.Close)
+	srv := httptest.NewServer(newServer(tok).handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Запрос к сервису с разбором JSON ответа в v
+func request(t *testing.T, method, url, contentType, body string, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: decoding response: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestServeTokenize(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		contentType, body string
+		want              []string
+	}{
+		{"", "hello", []string{"hello"}},
+		{"text/plain", "hello\nworld foo\n2019\nИтого\n", []string{"hello", "world", "foo", "2019", "Итого"}},
+		{"application/json", `{"text": "Привет"}`, []string{"Привет"}},
+		{"", "", []string{}},
+	}
+	for _, tt := range tests {
+		var response struct{ Tokens []string }
+		if status := request(t, "POST", srv.URL+"/tokenize", tt.contentType, tt.body, &response); status != http.StatusOK {
+			t.Errorf("POST /tokenize %q: status %d", tt.body, status)
+		}
+		if !slices.Equal(response.Tokens, tt.want) {
+			t.Errorf("POST /tokenize %q = %q, want %q", tt.body, response.Tokens, tt.want)
+		}
+	}
+
+	var response struct{ Error string }
+	if status := request(t, "POST", srv.URL+"/tokenize", "application/json", `{"body": "hello"}`, &response); status != http.StatusBadRequest || response.Error == "" {
+		t.Errorf("POST /tokenize without text field: status %d, error %q", status, response.Error)
+	}
+}
+
+func TestServeCount(t *testing.T) {
+	srv := newTestServer(t)
+	var response struct{ Counts map[string]int }
+	request(t, "POST", srv.URL+"/count", "", "hello\nhello world", &response)
+	if want := map[string]int{"hello": 2, "world": 1}; !maps.Equal(response.Counts, want) {
+		t.Errorf("POST /count = %v, want %v", response.Counts, want)
+	}
+
+	// Без accumulate частоты не накапливаются
+	response.Counts = nil
+	request(t, "GET", srv.URL+"/vocabulary", "", "", &response)
+	if len(response.Counts) != 0 {
+		t.Errorf("GET /vocabulary = %v, want empty", response.Counts)
+	}
+
+	request(t, "POST", srv.URL+"/count?accumulate=true", "", "hello", &response)
+	request(t, "POST", srv.URL+"/count?accumulate=true", "", "hello world", &response)
+	response.Counts = nil
+	request(t, "GET", srv.URL+"/vocabulary", "", "", &response)
+	if want := map[string]int{"hello": 2, "world": 1}; !maps.Equal(response.Counts, want) {
+		t.Errorf("GET /vocabulary = %v, want %v", response.Counts, want)
+	}
+}
@@ -0,0 +1,237 @@
+package bpe
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EndOfWord — суффикс последнего символа слова, как в subword-nmt
+const EndOfWord = "</w>"
+
+// Pair — пара соседних подслов
+type Pair struct {
+	Left, Right string
+}
+
+// Model — результат обучения BPE: таблица слияний и словарь подслов
+type Model struct {
+	Merges []Pair
+	Vocab  []string // Подслова в порядке идентификаторов: символы, затем результаты слияний
+}
+
+type word struct {
+	symbols []string
+	count   int
+}
+
+// Train обучает таблицу слияний BPE на частотах слов.
+// Слова разбиваются на символы, затем numMerges раз объединяется самая частая пара соседних подслов.
+func Train(vocab map[string]int, numMerges int) *Model {
+	words, alphabet := splitWords(vocab, func(runes []rune) []string {
+		symbols := make([]string, len(runes))
+		for i, r := range runes {
+			symbols[i] = string(r)
+		}
+		symbols[len(symbols)-1] += EndOfWord
+		return symbols
+	})
+
+	model := &Model{Vocab: alphabet}
+	mergePairs(words, func(p Pair) string {
+		return p.Left + p.Right
+	}, func(p Pair, merged string) bool {
+		if len(model.Merges) >= numMerges {
+			return false
+		}
+		model.Merges = append(model.Merges, p)
+		model.Vocab = append(model.Vocab, merged)
+		return true
+	})
+
+	return model
+}
+
+// Разбиение слов словаря на начальные подслова.
+// Возвращает слова и отсортированный алфавит начальных подслов.
+func splitWords(vocab map[string]int, split func(runes []rune) []string) ([]*word, []string) {
+	// Сортируем слова, чтобы результат не зависел от порядка обхода словаря
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	words := make([]*word, 0, len(tokens))
+	symbolSet := make(map[string]struct{})
+	for _, token := range tokens {
+		runes := []rune(token)
+		if len(runes) == 0 || vocab[token] <= 0 {
+			continue
+		}
+		symbols := split(runes)
+		for _, symbol := range symbols {
+			symbolSet[symbol] = struct{}{}
+		}
+		words = append(words, &word{symbols: symbols, count: vocab[token]})
+	}
+
+	alphabet := make([]string, 0, len(symbolSet))
+	for symbol := range symbolSet {
+		alphabet = append(alphabet, symbol)
+	}
+	sort.Strings(alphabet)
+
+	return words, alphabet
+}
+
+// Последовательное слияние самых частых пар соседних подслов.
+// join строит подслово из пары, accept получает каждое слияние и может остановить обучение.
+func mergePairs(words []*word, join func(Pair) string, accept func(p Pair, merged string) bool) {
+	// Подсчет пар и индекс слов, в которых они встречаются
+	counts := make(map[Pair]int)
+	where := make(map[Pair]map[int]struct{})
+	queue := &pairQueue{}
+	for i, w := range words {
+		for j := 0; j+1 < len(w.symbols); j++ {
+			p := Pair{w.symbols[j], w.symbols[j+1]}
+			counts[p] += w.count
+			if where[p] == nil {
+				where[p] = make(map[int]struct{})
+			}
+			where[p][i] = struct{}{}
+		}
+	}
+	for p, c := range counts {
+		heap.Push(queue, pairCount{p, c})
+	}
+
+	for queue.Len() > 0 {
+		top := heap.Pop(queue).(pairCount)
+		if counts[top.pair] != top.count {
+			continue // Устаревшая запись очереди
+		}
+		if top.count <= 0 {
+			break
+		}
+
+		best := top.pair
+		merged := join(best)
+		if !accept(best, merged) {
+			break
+		}
+
+		changed := make(map[Pair]struct{})
+		for i := range where[best] {
+			w := words[i]
+
+			// Убираем пары слова до слияния
+			for j := 0; j+1 < len(w.symbols); j++ {
+				p := Pair{w.symbols[j], w.symbols[j+1]}
+				counts[p] -= w.count
+				changed[p] = struct{}{}
+			}
+
+			symbols := make([]string, 0, len(w.symbols))
+			for j := 0; j < len(w.symbols); j++ {
+				if j+1 < len(w.symbols) && w.symbols[j] == best.Left && w.symbols[j+1] == best.Right {
+					symbols = append(symbols, merged)
+					j++
+				} else {
+					symbols = append(symbols, w.symbols[j])
+				}
+			}
+			w.symbols = symbols
+
+			// Добавляем пары слова после слияния
+			for j := 0; j+1 < len(w.symbols); j++ {
+				p := Pair{w.symbols[j], w.symbols[j+1]}
+				counts[p] += w.count
+				changed[p] = struct{}{}
+				if where[p] == nil {
+					where[p] = make(map[int]struct{})
+				}
+				where[p][i] = struct{}{}
+			}
+		}
+		delete(where, best)
+
+		for p := range changed {
+			if counts[p] <= 0 {
+				delete(counts, p)
+				continue
+			}
+			heap.Push(queue, pairCount{p, counts[p]})
+		}
+	}
+}
+
+// WriteMerges сохраняет таблицу слияний в формате merges.txt
+func (m *Model) WriteMerges(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating merges file: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintln(writer, "#version: 0.2")
+	for _, p := range m.Merges {
+		fmt.Fprintf(writer, "%s %s\n", p.Left, p.Right)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing merges file: %v", err)
+	}
+	return nil
+}
+
+// WriteVocab сохраняет словарь подслов в формате vocab.json (подслово -> идентификатор)
+func (m *Model) WriteVocab(filePath string) error {
+	ids := make(map[string]int, len(m.Vocab))
+	for i, token := range m.Vocab {
+		ids[token] = i
+	}
+
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(ids); err != nil {
+		return fmt.Errorf("error encoding vocab: %v", err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(buf.String()), 0644); err != nil {
+		return fmt.Errorf("error writing vocab file: %v", err)
+	}
+	return nil
+}
+
+// Очередь пар по убыванию частоты; при равенстве — по алфавиту для детерминированности
+type pairCount struct {
+	pair  Pair
+	count int
+}
+
+type pairQueue []pairCount
+
+func (q pairQueue) Len() int { return len(q) }
+func (q pairQueue) Less(i, j int) bool {
+	if q[i].count != q[j].count {
+		return q[i].count > q[j].count
+	}
+	if q[i].pair.Left != q[j].pair.Left {
+		return q[i].pair.Left < q[j].pair.Left
+	}
+	return q[i].pair.Right < q[j].pair.Right
+}
+func (q pairQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pairQueue) Push(x interface{}) { *q = append(*q, x.(pairCount)) }
+func (q *pairQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
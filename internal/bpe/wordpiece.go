@@ -0,0 +1,81 @@
+package bpe
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ContinuationPrefix — префикс подслов, продолжающих слово
+const ContinuationPrefix = "##"
+
+// SpecialTokens — служебные токены BERT, которые идут в начале словаря WordPiece
+var SpecialTokens = []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "[MASK]"}
+
+// WordPieceModel — словарь WordPiece в порядке идентификаторов
+type WordPieceModel struct {
+	Vocab []string
+}
+
+// TrainWordPiece строит словарь WordPiece заданного размера.
+// Используется частотная эвристика: как в BPE, объединяется самая частая пара соседних подслов,
+// но продолжения слова помечаются префиксом ## вместо суффикса конца слова.
+func TrainWordPiece(vocab map[string]int, size int) *WordPieceModel {
+	words, alphabet := splitWords(vocab, func(runes []rune) []string {
+		symbols := make([]string, len(runes))
+		for i, r := range runes {
+			if i == 0 {
+				symbols[i] = string(r)
+			} else {
+				symbols[i] = ContinuationPrefix + string(r)
+			}
+		}
+		return symbols
+	})
+
+	model := &WordPieceModel{}
+	seen := make(map[string]struct{})
+	add := func(token string) {
+		if _, ok := seen[token]; !ok {
+			seen[token] = struct{}{}
+			model.Vocab = append(model.Vocab, token)
+		}
+	}
+	for _, token := range SpecialTokens {
+		add(token)
+	}
+	for _, symbol := range alphabet {
+		add(symbol)
+	}
+
+	mergePairs(words, func(p Pair) string {
+		return p.Left + strings.TrimPrefix(p.Right, ContinuationPrefix)
+	}, func(p Pair, merged string) bool {
+		if len(model.Vocab) >= size {
+			return false
+		}
+		add(merged)
+		return true
+	})
+
+	return model
+}
+
+// WriteVocab сохраняет словарь в формате vocab.txt (по одному токену на строку)
+func (m *WordPieceModel) WriteVocab(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating vocab file: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, token := range m.Vocab {
+		fmt.Fprintln(writer, token)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing vocab file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CSVProcessor извлекает из табличного файла только один текстовый столбец.
+// Остальные столбцы (идентификаторы, категории и т.п.) игнорируются.
+type CSVProcessor struct {
+	Comma rune
+	// Column — индекс столбца (с нуля) или имя столбца из первой строки-заголовка.
+	// При указании индекса первая строка считается данными.
+	Column string
+}
+
+// Каждое значение столбца — отдельный текст, строки многострочных полей тоже
+func (p *CSVProcessor) WholeLines() bool {
+	return true
+}
+
+func (p *CSVProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = p.Comma
+	reader.FieldsPerRecord = -1 // Допускаем строки с разным числом полей
+
+	column, err := strconv.Atoi(p.Column)
+	if err != nil {
+		// Ищем столбец по имени в заголовке
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("error reading csv header: %v", err)
+		}
+		column = -1
+		for i, name := range header {
+			if name == p.Column {
+				column = i
+				break
+			}
+		}
+		if column < 0 {
+			return nil, fmt.Errorf("column %q not found in csv header", p.Column)
+		}
+	} else if column < 0 {
+		return nil, fmt.Errorf("invalid csv column index %d", column)
+	}
+
+	// Отдаем значения столбца потоком, по одному полю на строку.
+	// Многострочные поля в кавычках csv.Reader собирает целиком.
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				pw.Close()
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("error reading csv record: %v", err))
+				return
+			}
+			if column >= len(record) {
+				continue // В строке нет нужного столбца
+			}
+			if _, err := io.WriteString(pw, record[column]+"\n"); err != nil {
+				return // Читатель закрыл поток
+			}
+		}
+	}()
+
+	return pr, nil
+}
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Пространство имен WordprocessingML
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// DOCXProcessor извлекает текст из DOCX файла, разбирая XML частей документа напрямую.
+// Кроме основного текста извлекаются таблицы (их ячейки состоят из абзацев),
+// колонтитулы и сноски. Каждый абзац выводится отдельной строкой.
+type DOCXProcessor struct{}
+
+// Каждый абзац и каждая ячейка таблицы выводятся отдельной строкой
+func (p *DOCXProcessor) WholeLines() bool {
+	return true
+}
+
+func (p *DOCXProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	// Для чтения zip архива нужен произвольный доступ
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCX archive: %v", err)
+	}
+
+	var text bytes.Buffer
+	for _, part := range docxTextParts(archive) {
+		if err := extractDOCXPart(part, &text); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", part.Name, err)
+		}
+	}
+
+	return io.NopCloser(strings.NewReader(sanitizeDOCXText(text.String()))), nil
+}
+
+// Остатки XML разметки в тексте (например, <w:t> или </w:r>)
+var xmlTagPattern = regexp.MustCompile(`</?[A-Za-z][\w.-]*:[\w.-]+(\s[^<>]*)?/?>`)
+
+// Очистка текста от экранированных сущностей и тегов, оставшихся после разбора.
+// Такие остатки появляются в документах, сохраненных с двойным экранированием
+// (&amp;amp; в XML дает &amp; в тексте), и иначе становятся токенами вроде "amp" или "w:t".
+// Управляющие символы из сущностей вроде &#1; заменяются пробелом, кроме \n и \t.
+func sanitizeDOCXText(text string) string {
+	text = html.UnescapeString(text)
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return ' '
+		}
+		return r
+	}, text)
+	return xmlTagPattern.ReplaceAllString(text, " ")
+}
+
+// Части документа с текстом: основной текст, затем колонтитулы и сноски
+func docxTextParts(archive *zip.Reader) []*zip.File {
+	var body, other []*zip.File
+	for _, f := range archive.File {
+		dir, name := path.Split(f.Name)
+		if dir != "word/" {
+			continue
+		}
+		switch {
+		case name == "document.xml":
+			body = append(body, f)
+		case strings.HasPrefix(name, "header") && strings.HasSuffix(name, ".xml"),
+			strings.HasPrefix(name, "footer") && strings.HasSuffix(name, ".xml"),
+			name == "footnotes.xml", name == "endnotes.xml":
+			other = append(other, f)
+		}
+	}
+	sort.Slice(other, func(i, j int) bool { return other[i].Name < other[j].Name })
+	return append(body, other...)
+}
+
+// Извлечение текста одной XML части: содержимое <w:t>, по строке на абзац <w:p>
+func extractDOCXPart(f *zip.File, w *bytes.Buffer) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(rc)
+	inText := false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Space != wordNamespace {
+				continue
+			}
+			switch el.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				w.WriteByte(' ')
+			case "br", "cr":
+				w.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if el.Name.Space != wordNamespace {
+				continue
+			}
+			switch el.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				w.WriteByte('\n')
+			case "tc":
+				// Соседние ячейки таблицы не должны склеиваться
+				w.WriteByte(' ')
+			}
+		case xml.CharData:
+			if inText {
+				w.Write(el)
+			}
+		}
+	}
+}
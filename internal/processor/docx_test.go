@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// Документ с двойным экранированием: сущности, теги и управляющие символы
+// не должны попадать в текст
+func TestDOCXSanitizesLeaks(t *testing.T) {
+	f, err := os.Open("testdata/leaks.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rc, err := (&DOCXProcessor{}).Process(f)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+
+	if !strings.Contains(text, "Том & Джерри") {
+		t.Errorf("text %q does not contain %q", text, "Том & Джерри")
+	}
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			t.Errorf("text %q contains control character %U", text, r)
+		}
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '&'
+	}) {
+		words[word] = true
+	}
+	for _, want := range []string{"Том", "Джерри", "кот", "и", "пес", "начало", "конец", "хвост"} {
+		if !words[want] {
+			t.Errorf("word %q missing from %q", want, text)
+		}
+	}
+	for _, leak := range []string{"amp", "lt", "gt", "nbsp", "w:t", "<w:t>", "</w:t>"} {
+		if words[leak] || strings.Contains(text, leak) {
+			t.Errorf("text %q leaks %q", text, leak)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONLProcessor извлекает из каждой строки JSONL файла значение одного поля.
+// Ключи, URL и прочие метаданные в словарь не попадают.
+type JSONLProcessor struct {
+	// Field — путь к полю через точку, например .text или .meta.body
+	Field string
+	Name  string
+	Log   func(message string)
+}
+
+// Каждое значение поля — отдельный текст
+func (p *JSONLProcessor) WholeLines() bool {
+	return true
+}
+
+func (p *JSONLProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	path := strings.Split(strings.TrimPrefix(p.Field, "."), ".")
+
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(r)
+		totalLines, skippedLines := 0, 0
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				totalLines++
+				text, ok := extractField(line, path)
+				if !ok {
+					skippedLines++
+				} else if _, err := io.WriteString(pw, text+"\n"); err != nil {
+					return // Читатель закрыл поток
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+
+		if skippedLines > 0 && p.Log != nil {
+			p.Log(fmt.Sprintf("Skipped %d/%d lines in %s: invalid JSON or missing field %q", skippedLines, totalLines, p.Name, p.Field))
+		}
+		pw.Close()
+	}()
+
+	return pr, nil
+}
+
+// Извлечение строкового значения по пути из JSON объекта
+func extractField(line []byte, path []string) (string, bool) {
+	var value interface{}
+	if err := json.Unmarshal(line, &value); err != nil {
+		return "", false
+	}
+
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+
+	text, ok := value.(string)
+	return text, ok
+}
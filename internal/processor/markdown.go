@@ -0,0 +1,278 @@
+package processor
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkdownProcessor извлекает из Markdown файла текст без разметки: заголовки, списки,
+// цитаты и таблицы — без служебных символов, ссылки и изображения — только подписи,
+// без выделения и HTML-тегов. Front matter (YAML между строками ---) пропускается.
+// Код — блоки ``` и ~~~, <pre>, код в строке и <code> — обрабатывается согласно Code.
+// Файл разбирается построчно; конструкции, которые не распознаны, остаются как текст.
+type MarkdownProcessor struct {
+	Code     string            // Обработка кода: CodeKeep, CodeExclude или CodeSeparate
+	CodeSink func(line string) // Получает строки кода при CodeSeparate
+	KeepURLs bool              // Сохранять адреса ссылок и изображений
+}
+
+// Заголовки, пункты списков и ячейки выводятся отдельными строками
+func (p *MarkdownProcessor) WholeLines() bool {
+	return true
+}
+
+func (p *MarkdownProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		var fence string // Открывающая последовательность текущего блока кода
+		frontMatter, comment := false, false
+		for lineNumber := 1; scanner.Scan(); lineNumber++ {
+			line := scanner.Text()
+			trimmed := strings.TrimSpace(line)
+
+			var text string
+			switch {
+			case lineNumber == 1 && trimmed == "---":
+				frontMatter = true
+			case frontMatter:
+				frontMatter = trimmed != "---" && trimmed != "..."
+			case fence == preClose:
+				before, _, closed := strings.Cut(line, preClose)
+				text = p.code(before)
+				if closed {
+					fence = ""
+				}
+			case fence != "":
+				if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					fence = ""
+				} else {
+					text = p.code(line)
+				}
+			case comment:
+				if _, rest, ok := strings.Cut(line, "-->"); ok {
+					comment = false
+					text = p.inline(rest)
+				}
+			case codeFence(trimmed) != "":
+				fence = codeFence(trimmed)
+			case strings.HasPrefix(trimmed, "<pre>") || strings.HasPrefix(trimmed, "<pre "):
+				// Текст после открывающего тега тоже код
+				_, rest, _ := strings.Cut(line, ">")
+				before, _, closed := strings.Cut(rest, preClose)
+				text = p.code(htmlTagPattern.ReplaceAllString(before, ""))
+				if !closed {
+					fence = preClose
+				}
+			default:
+				if before, _, ok := strings.Cut(line, "<!--"); ok && !strings.Contains(line, "-->") {
+					comment = true
+					line = before
+				}
+				text = p.block(line)
+			}
+
+			if text == "" {
+				continue
+			}
+			if _, err := io.WriteString(pw, text+"\n"); err != nil {
+				return // Читатель закрыл поток
+			}
+		}
+		pw.CloseWithError(scanner.Err())
+	}()
+
+	return pr, nil
+}
+
+// Максимальная длина строки Markdown файла
+const maxLineSize = 16 * 1024 * 1024
+
+// Закрывающий тег HTML-блока кода
+const preClose = "</pre>"
+
+// Строка кода: остается в тексте (CodeKeep) или пропускается, а при CodeSeparate
+// передается в CodeSink
+func (p *MarkdownProcessor) code(line string) string {
+	switch p.Code {
+	case CodeKeep:
+		return line
+	case CodeSeparate:
+		if p.CodeSink != nil && strings.TrimSpace(line) != "" {
+			p.CodeSink(line)
+		}
+	}
+	return ""
+}
+
+// Открывающая последовательность блока кода (``` или ~~~, не короче трех символов)
+func codeFence(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			n := len(line) - len(strings.TrimLeft(line, c))
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+var (
+	// Разделители: ---, ***, ___ (в том числе с пробелами) и подчеркивания заголовков ===
+	thematicBreakPattern = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|=+[ \t]*)$`)
+	// Строка-разделитель таблицы: |---|:--:|
+	tableDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	// Определение ссылки: [id]: url "заголовок"
+	linkDefinitionPattern = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?(\S*?)>?(?:\s+.*)?$`)
+	// Маркеры заголовков, цитат, списков и задач в начале строки
+	blockPrefixPattern = regexp.MustCompile(`^\s*(?:>\s*)*(?:#{1,6}(?:\s+|$)|(?:[-*+]|\d{1,9}[.)])\s+(?:\[[ xX]\]\s+)?)?`)
+	// Закрывающие символы # заголовка
+	headingSuffixPattern = regexp.MustCompile(`\s+#+\s*$`)
+
+	// Код в строке: `код`, ``код с ` внутри`` или <code>код</code>
+	inlineCodePattern = regexp.MustCompile("``(.+?)``|`([^`]+)`|<code>(.*?)</code>")
+	// Ссылки и изображения: [текст](url "заголовок"), ![подпись](url)
+	inlineLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	// Ссылки по определению: [текст][id]
+	referenceLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\[[^\]]*\]`)
+	// Автоссылки: <https://example.com>
+	autolinkPattern = regexp.MustCompile(`<((?:https?|ftp|mailto):[^<>\s]+)>`)
+	// HTML-комментарии в пределах строки
+	htmlCommentPattern = regexp.MustCompile(`<!--.*?-->`)
+	// HTML-теги
+	htmlTagPattern = regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+	// Выделение * и зачеркивание ~~
+	emphasisPattern = regexp.MustCompile(`\*+|~~`)
+)
+
+// Текст строки вне блоков кода
+func (p *MarkdownProcessor) block(line string) string {
+	if thematicBreakPattern.MatchString(line) || strings.Contains(line, "-") && tableDelimiterPattern.MatchString(line) {
+		return ""
+	}
+	if m := linkDefinitionPattern.FindStringSubmatch(line); m != nil {
+		if p.KeepURLs {
+			return m[1]
+		}
+		return ""
+	}
+
+	line = blockPrefixPattern.ReplaceAllString(line, "")
+	line = headingSuffixPattern.ReplaceAllString(line, "")
+	if strings.HasPrefix(strings.TrimSpace(line), "|") {
+		line = strings.ReplaceAll(line, "|", " ")
+	}
+	return strings.TrimSpace(p.inline(line))
+}
+
+// Текст без разметки внутри строки. Код в строке сохраняется как есть или пропускается.
+func (p *MarkdownProcessor) inline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlineCodePattern.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(p.inlineText(line[last:m[0]]))
+		code := m[2:4]
+		for i := 4; code[0] < 0; i += 2 {
+			code = m[i : i+2]
+		}
+		if text := p.code(line[code[0]:code[1]]); text != "" {
+			b.WriteString(text)
+		} else {
+			b.WriteByte(' ')
+		}
+		last = m[1]
+	}
+	b.WriteString(p.inlineText(line[last:]))
+	return b.String()
+}
+
+// Снятие разметки с текста вне кода
+func (p *MarkdownProcessor) inlineText(text string) string {
+	// Экранированные символы (\*) временно заменяются, чтобы не считаться разметкой
+	text = escapeMarkdown(text)
+
+	text = inlineLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := inlineLinkPattern.FindStringSubmatch(link)
+		if p.KeepURLs && m[2] != "" {
+			return m[1] + " " + m[2]
+		}
+		return m[1]
+	})
+	text = referenceLinkPattern.ReplaceAllString(text, "$1")
+	text = autolinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		if p.KeepURLs {
+			return link[1 : len(link)-1]
+		}
+		return " "
+	})
+	text = htmlCommentPattern.ReplaceAllString(text, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = emphasisPattern.ReplaceAllString(text, "")
+	text = stripEmphasisUnderscores(text)
+
+	return unescapeMarkdown(text)
+}
+
+// Удаление подчеркиваний выделения (_текст_, __текст__); подчеркивания внутри слов
+// (snake_case) сохраняются
+func stripEmphasisUnderscores(text string) string {
+	if !strings.Contains(text, "_") {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if text[i] != '_' {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			b.WriteString(text[i : i+size])
+			i += size
+			continue
+		}
+		end := i
+		for end < len(text) && text[end] == '_' {
+			end++
+		}
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if wordRune(before) && wordRune(after) {
+			b.WriteString(text[i:end])
+		}
+		i = end
+	}
+	return b.String()
+}
+
+func wordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// Экранируемые символы Markdown заменяются символами области для частного использования
+const markdownEscapeBase = 0xE000
+
+func escapeMarkdown(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()<>#+-.!|~", text[i+1]) >= 0 {
+			b.WriteRune(markdownEscapeBase + rune(text[i+1]))
+			i++
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+func unescapeMarkdown(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= markdownEscapeBase && r < markdownEscapeBase+utf8.RuneSelf {
+			return r - markdownEscapeBase
+		}
+		return r
+	}, text)
+}
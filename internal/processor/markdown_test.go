@@ -0,0 +1,36 @@
+package processor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Заголовки и пункты списков выводятся отдельными строками без разметки
+func TestMarkdownHeadingsAndLists(t *testing.T) {
+	p := &MarkdownProcessor{}
+	rc, err := p.Process(strings.NewReader("# Introduction\n\n- apples\n* [x] pears\n1. plums\n> quote\n\nSome **bold** text\n"))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	want := []string{"Introduction", "apples", "pears", "plums", "quote", "Some bold text"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if !WholeLines(p) || !WholeLines(&GzipProcessor{Inner: p}) {
+		t.Error("Markdown output is not counted by whole lines")
+	}
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Внешние программы распознавания: pdftoppm (poppler-utils) переводит страницу PDF
+// в изображение, tesseract распознает на нем текст
+const (
+	ocrRasterizer = "pdftoppm"
+	ocrEngine     = "tesseract"
+)
+
+// Разрешение изображения страницы для распознавания, точек на дюйм
+const ocrResolution = 300
+
+// Запуск программы с возвратом ее вывода; в тестах заменяется
+var runCommand = defaultRunCommand
+
+func defaultRunCommand(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return out, nil
+}
+
+// CheckOCR проверяет, что программы распознавания доступны в PATH
+func CheckOCR() error {
+	for _, name := range []string{ocrRasterizer, ocrEngine} {
+		if _, err := exec.LookPath(name); err != nil {
+			return fmt.Errorf("OCR requires %s and %s in PATH: %v", ocrRasterizer, ocrEngine, err)
+		}
+	}
+	return nil
+}
+
+// pdfOCR распознает страницы одного PDF файла. Файл записывается во временную папку
+// при первом распознавании.
+type pdfOCR struct {
+	lang string
+	data []byte
+	dir  string
+}
+
+// Текст страницы с номером page (с единицы)
+func (o *pdfOCR) page(page int) (string, error) {
+	if o.dir == "" {
+		dir, err := os.MkdirTemp("", "vocab-ocr-")
+		if err != nil {
+			return "", err
+		}
+		o.dir = dir
+		if err := os.WriteFile(filepath.Join(dir, "document.pdf"), o.data, 0600); err != nil {
+			return "", err
+		}
+	}
+
+	n := strconv.Itoa(page)
+	image := filepath.Join(o.dir, "page"+n)
+	if _, err := runCommand(ocrRasterizer, "-f", n, "-l", n, "-r", strconv.Itoa(ocrResolution), "-gray", "-png", "-singlefile",
+		filepath.Join(o.dir, "document.pdf"), image); err != nil {
+		return "", err
+	}
+	defer os.Remove(image + ".png")
+	args := []string{image + ".png", "stdout"}
+	if o.lang != "" {
+		args = append(args, "-l", o.lang)
+	}
+	text, err := runCommand(ocrEngine, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(text)), nil
+}
+
+// Удаление временных файлов
+func (o *pdfOCR) close() {
+	if o.dir != "" {
+		os.RemoveAll(o.dir)
+	}
+}
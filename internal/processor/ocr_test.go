@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Страницы без текста распознаются, страницы с текстом — нет
+func TestPDFOCRFallback(t *testing.T) {
+	var calls [][]string
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		if name == ocrEngine {
+			if strings.Contains(args[0], "page3") {
+				return nil, errors.New("tesseract: exit status 1")
+			}
+			return []byte("Распознанный текст\n"), nil
+		}
+		// Растрирование: документ должен быть записан во временную папку
+		if _, err := os.Stat(args[len(args)-2]); err != nil {
+			return nil, err
+		}
+		return nil, os.WriteFile(args[len(args)-1]+".png", nil, 0600)
+	}
+	t.Cleanup(func() { runCommand = defaultRunCommand })
+
+	data := testPDF(
+		flateStream("BT /F1 12 Tf (text) Tj ET"),
+		flateStream("q 612 0 0 792 0 0 cm /Im1 Do Q"),
+		flateStream(""),
+	)
+	var logged []string
+	p := &PDFProcessor{Name: "scan.pdf", OCR: true, OCRLang: "rus+eng", Log: func(message string) { logged = append(logged, message) }}
+	rc, err := p.Process(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	defer rc.Close()
+	text, _ := io.ReadAll(rc)
+
+	if string(text) != "text\nРаспознанный текст\n" {
+		t.Errorf("text = %q", text)
+	}
+	want := []string{
+		"OCR fallback for page 2 of scan.pdf",
+		"OCR fallback for page 3 of scan.pdf",
+		"Skipped page 3 of scan.pdf: OCR failed: tesseract: exit status 1",
+		"Skipped 1/3 pages in scan.pdf",
+	}
+	if !slices.Equal(logged, want) {
+		t.Errorf("logged %q, want %q", logged, want)
+	}
+	if len(calls) != 4 || calls[0][0] != ocrRasterizer || !slices.Equal(calls[0][1:5], []string{"-f", "2", "-l", "2"}) ||
+		!slices.Equal(calls[1][2:], []string{"stdout", "-l", "rus+eng"}) {
+		t.Errorf("commands %q", calls)
+	}
+	// Временные файлы удаляются
+	if dir := calls[0][len(calls[0])-2]; fileExists(dir) {
+		t.Errorf("temporary file %s was not removed", dir)
+	}
+}
+
+// Без OCR страницы без текста просто пустые
+func TestPDFWithoutOCR(t *testing.T) {
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		t.Errorf("unexpected command %s", name)
+		return nil, nil
+	}
+	t.Cleanup(func() { runCommand = defaultRunCommand })
+
+	text, logged, err := processPDF(t, testPDF(flateStream("BT /F1 12 Tf (text) Tj ET"), flateStream("")))
+	if err != nil || text != "text\n\n" || len(logged) != 0 {
+		t.Errorf("Process = %q, %q, %v", text, logged, err)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,973 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDFProcessor извлекает текст PDF файла постранично без внешних программ: разбирает
+// объекты файла (в том числе из потоков объектов), дерево страниц и операторы вывода
+// текста (Tj, TJ, ', ") в потоках содержимого страниц и форм. Коды символов переводятся
+// в Unicode по таблице ToUnicode шрифта, а при ее отсутствии — как WinAnsiEncoding.
+// Каждая строка текста страницы выводится отдельной строкой.
+//
+// Страница, которую не удалось разобрать, пропускается с сообщением в Log, и обработка
+// продолжается со следующей. Ошибка возвращается, только если не извлечена ни одна страница.
+// Зашифрованные файлы не поддерживаются. Текст шрифтов с двухбайтовыми кодами без
+// ToUnicode не извлекается. С OCR страницы без текста (сканы) распознаются внешними
+// программами.
+type PDFProcessor struct {
+	Name    string
+	OCR     bool   // Распознавать страницы без текста
+	OCRLang string // Языки tesseract, например rus+eng
+	Log     func(message string)
+}
+
+// Каждая строка страницы выводится отдельной строкой
+func (p *PDFProcessor) WholeLines() bool {
+	return true
+}
+
+func (p *PDFProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	// Объекты PDF ссылаются друг на друга, поэтому нужен произвольный доступ
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PDF: %v", err)
+	}
+	pages := doc.pages()
+	if len(pages) == 0 {
+		return nil, errors.New("invalid PDF: no pages found")
+	}
+
+	ocr := &pdfOCR{lang: p.OCRLang, data: data}
+	defer ocr.close()
+
+	var text strings.Builder
+	var firstErr error
+	skipped := 0
+	for i, page := range pages {
+		pageText, err := doc.pageText(page)
+		if err == nil && pageText == "" && p.OCR {
+			p.log(fmt.Sprintf("OCR fallback for page %d of %s", i+1, p.Name))
+			if pageText, err = ocr.page(i + 1); err != nil {
+				err = fmt.Errorf("OCR failed: %v", err)
+			}
+		}
+		if err != nil {
+			skipped++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("page %d: %v", i+1, err)
+			}
+			p.log(fmt.Sprintf("Skipped page %d of %s: %v", i+1, p.Name, err))
+			continue
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+	if skipped == len(pages) {
+		return nil, fmt.Errorf("no pages could be extracted (%d pages): %v", len(pages), firstErr)
+	}
+	if skipped > 0 {
+		p.log(fmt.Sprintf("Skipped %d/%d pages in %s", skipped, len(pages), p.Name))
+	}
+
+	return io.NopCloser(strings.NewReader(text.String())), nil
+}
+
+func (p *PDFProcessor) log(message string) {
+	if p.Log != nil {
+		p.Log(message)
+	}
+}
+
+// Объекты PDF: числа — float64, логические значения — bool, null — nil
+type (
+	pdfName     string
+	pdfString   string // Байты строки без перекодирования
+	pdfOperator string // Оператор потока содержимого или ключевое слово
+	pdfArray    []interface{}
+	pdfDict     map[pdfName]interface{}
+	pdfRef      struct{ num, gen int }
+	pdfStream   struct {
+		dict pdfDict
+		data []byte // Данные до декодирования фильтров
+	}
+)
+
+// Ограничение глубины вложенности объектов, ссылок и форм
+const pdfMaxDepth = 32
+
+// pdfLexer читает объекты PDF из среза байтов
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// Пропуск пробелов и комментариев
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Чтение последовательности обычных символов (имени, числа, ключевого слова)
+func (l *pdfLexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// Чтение следующего объекта. Ключевые слова и операторы возвращаются как pdfOperator;
+// конец данных — io.EOF.
+func (l *pdfLexer) object(depth int) (interface{}, error) {
+	if depth > pdfMaxDepth {
+		return nil, errors.New("objects nested too deep")
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(decodePDFName(l.regular())), nil
+	case c == '(':
+		return l.literalString()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict(depth)
+	case c == '<':
+		return l.hexString()
+	case c == '[':
+		l.pos++
+		var array pdfArray
+		for {
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == ']' {
+				l.pos++
+				return array, nil
+			}
+			value, err := l.object(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfOperator(c), nil
+	}
+
+	word := l.regular()
+	if word == "" {
+		return nil, fmt.Errorf("unexpected byte %q at offset %d", l.data[l.pos], l.pos)
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return pdfOperator(word), nil
+	}
+	// Ссылка на объект: "номер поколение R"
+	if number >= 0 && number == float64(int(number)) {
+		save := l.pos
+		l.skipSpace()
+		gen := l.regular()
+		l.skipSpace()
+		if g, err := strconv.Atoi(gen); err == nil && l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+			(l.pos+1 == len(l.data) || isPDFSpace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+			l.pos++
+			return pdfRef{num: int(number), gen: g}, nil
+		}
+		l.pos = save
+	}
+	return number, nil
+}
+
+func (l *pdfLexer) dict(depth int) (pdfDict, error) {
+	dict := make(pdfDict)
+	for {
+		l.skipSpace()
+		if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+			l.pos += 2
+			return dict, nil
+		}
+		key, err := l.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key %v is not a name", key)
+		}
+		value, err := l.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		dict[name] = value
+	}
+}
+
+// Строка в скобках с экранированием и вложенными скобками
+func (l *pdfLexer) literalString() (pdfString, error) {
+	l.pos++
+	var b []byte
+	for nesting := 0; l.pos < len(l.data); l.pos++ {
+		c := l.data[l.pos]
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			if nesting == 0 {
+				l.pos++
+				return pdfString(b), nil
+			}
+			nesting--
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.data) {
+				return "", io.ErrUnexpectedEOF
+			}
+			c = l.data[l.pos]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Перенос строки после обратной косой черты не входит в строку
+				if c == '\r' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if '0' <= c && c <= '7' {
+					value := 0
+					for n := 0; n < 3 && l.pos < len(l.data) && '0' <= l.data[l.pos] && l.data[l.pos] <= '7'; n++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					l.pos--
+					c = byte(value)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return "", io.ErrUnexpectedEOF
+}
+
+// Строка в шестнадцатеричной записи <48656C6C6F>
+func (l *pdfLexer) hexString() (pdfString, error) {
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return "", io.ErrUnexpectedEOF
+	}
+	digits := make([]byte, 0, end)
+	for _, c := range l.data[l.pos+1 : l.pos+end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	l.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex string: %v", err)
+	}
+	return pdfString(b), nil
+}
+
+// Имя с кодами #xx
+func decodePDFName(name string) string {
+	if !strings.Contains(name, "#") {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if v, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// pdfDocument — объекты PDF файла по номерам
+type pdfDocument struct {
+	objects  map[int]interface{}
+	trailers []pdfDict // Словари trailer и потоков перекрестных ссылок
+}
+
+var (
+	pdfObjectPattern  = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`) // Начало косвенного объекта "номер поколение obj"
+	pdfTrailerPattern = regexp.MustCompile(`trailer\s*<<`)
+	pdfInlineImageEnd = regexp.MustCompile(`\sEI\b`) // Конец данных встроенного изображения
+)
+
+// Разбор файла. Таблица перекрестных ссылок не используется: объекты ищутся по всему
+// файлу, и более поздние определения (добавленные при обновлении) заменяют ранние.
+// Поэтому файлы с поврежденной таблицей ссылок тоже читаются.
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, errors.New("missing %PDF header")
+	}
+	doc := &pdfDocument{objects: make(map[int]interface{})}
+	for _, match := range pdfObjectPattern.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		l := &pdfLexer{data: data, pos: match[1]}
+		value, err := l.object(0)
+		if err != nil {
+			continue // Поврежденный объект; страницы, которым он нужен, будут пропущены
+		}
+		if dict, ok := value.(pdfDict); ok {
+			if stream, ok := l.streamData(dict); ok {
+				value = stream
+				if dict["Type"] == pdfName("XRef") {
+					doc.trailers = append(doc.trailers, dict)
+				}
+			}
+		}
+		doc.objects[num] = value
+	}
+
+	for _, match := range pdfTrailerPattern.FindAllIndex(data, -1) {
+		l := &pdfLexer{data: data, pos: match[1] - 2}
+		if value, err := l.object(0); err == nil {
+			if dict, ok := value.(pdfDict); ok {
+				doc.trailers = append(doc.trailers, dict)
+			}
+		}
+	}
+	for _, trailer := range doc.trailers {
+		if _, ok := trailer["Encrypt"]; ok {
+			return nil, errors.New("encrypted PDF is not supported")
+		}
+	}
+
+	doc.loadObjectStreams()
+	return doc, nil
+}
+
+// Данные потока после словаря. Длина берется из /Length, если это число, иначе
+// поток заканчивается перед ближайшим endstream.
+func (l *pdfLexer) streamData(dict pdfDict) (pdfStream, bool) {
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return pdfStream{}, false
+	}
+	start := l.pos + len("stream")
+	if bytes.HasPrefix(l.data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(l.data) && (l.data[start] == '\n' || l.data[start] == '\r') {
+		start++
+	}
+
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(l.data) {
+		end := start + int(length)
+		rest := bytes.TrimLeft(l.data[end:], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return pdfStream{dict: dict, data: l.data[start:end]}, true
+		}
+	}
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return pdfStream{}, false
+	}
+	data := bytes.TrimSuffix(l.data[start:start+end], []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return pdfStream{dict: dict, data: data}, true
+}
+
+// Объекты из потоков объектов (/Type /ObjStm, PDF 1.5). Объекты, определенные
+// в файле напрямую, не заменяются.
+func (d *pdfDocument) loadObjectStreams() {
+	var streams []pdfStream
+	for _, value := range d.objects {
+		if stream, ok := value.(pdfStream); ok && stream.dict["Type"] == pdfName("ObjStm") {
+			streams = append(streams, stream)
+		}
+	}
+	for _, stream := range streams {
+		data, err := decodePDFStream(stream)
+		if err != nil {
+			continue
+		}
+		n, _ := d.resolve(stream.dict["N"]).(float64)
+		first, _ := d.resolve(stream.dict["First"]).(float64)
+		if first < 0 || int(first) > len(data) {
+			continue
+		}
+		header := &pdfLexer{data: data[:int(first)]}
+		for i := 0; i < int(n); i++ {
+			num, err1 := header.object(0)
+			offset, err2 := header.object(0)
+			if err1 != nil || err2 != nil {
+				break
+			}
+			objNum, ok1 := num.(float64)
+			objOffset, ok2 := offset.(float64)
+			if !ok1 || !ok2 || int(first+objOffset) > len(data) {
+				break
+			}
+			if _, ok := d.objects[int(objNum)]; ok {
+				continue
+			}
+			l := &pdfLexer{data: data, pos: int(first + objOffset)}
+			if value, err := l.object(0); err == nil {
+				d.objects[int(objNum)] = value
+			}
+		}
+	}
+}
+
+// Значение с раскрытыми ссылками
+func (d *pdfDocument) resolve(value interface{}) interface{} {
+	for i := 0; i < pdfMaxDepth; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value = d.objects[ref.num]
+	}
+	return nil
+}
+
+// Словарь объекта или потока
+func (d *pdfDocument) dict(value interface{}) pdfDict {
+	switch v := d.resolve(value).(type) {
+	case pdfDict:
+		return v
+	case pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// Страницы в порядке дерева страниц каталога. Если каталог не найден или поврежден,
+// страницами считаются все объекты /Type /Page в порядке номеров.
+func (d *pdfDocument) pages() []pdfDict {
+	var root pdfDict
+	for _, trailer := range d.trailers {
+		if root = d.dict(trailer["Root"]); root != nil {
+			break
+		}
+	}
+	if root == nil {
+		for _, value := range d.objects {
+			if dict, ok := value.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+				root = dict
+				break
+			}
+		}
+	}
+
+	var pages []pdfDict
+	visited := make(map[int]bool)
+	var walk func(node interface{}, depth int)
+	walk = func(node interface{}, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil || depth > pdfMaxDepth {
+			return
+		}
+		if dict["Type"] == pdfName("Page") {
+			pages = append(pages, dict)
+			return
+		}
+		kids, _ := d.resolve(dict["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			walk(kid, depth+1)
+		}
+	}
+	if root != nil {
+		walk(root["Pages"], 0)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(d.objects))
+	for num, value := range d.objects {
+		if dict, ok := value.(pdfDict); ok && dict["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		pages = append(pages, d.objects[num].(pdfDict))
+	}
+	return pages
+}
+
+// Ресурсы страницы, в том числе унаследованные от узлов дерева страниц
+func (d *pdfDocument) resources(page pdfDict) pdfDict {
+	for node, depth := page, 0; node != nil && depth < pdfMaxDepth; node, depth = d.dict(node["Parent"]), depth+1 {
+		if resources := d.dict(node["Resources"]); resources != nil {
+			return resources
+		}
+	}
+	return nil
+}
+
+// Текст страницы
+func (d *pdfDocument) pageText(page pdfDict) (string, error) {
+	var content []byte
+	contents := d.resolve(page["Contents"])
+	parts, ok := contents.(pdfArray)
+	if !ok {
+		parts = pdfArray{contents}
+	}
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		stream, ok := d.resolve(part).(pdfStream)
+		if !ok {
+			return "", errors.New("content stream not found")
+		}
+		data, err := decodePDFStream(stream)
+		if err != nil {
+			return "", err
+		}
+		// Части потока содержимого соединяются через пробельный символ
+		content = append(append(content, data...), '\n')
+	}
+
+	w := &pdfTextWriter{}
+	if err := d.showText(content, d.resources(page), w, 0); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(w.b.String()), nil
+}
+
+// pdfTextWriter собирает текст с переносами строк и пробелами между словами.
+// Разделитель записывается только перед следующим текстом, и перенос строки
+// заменяет пробел.
+type pdfTextWriter struct {
+	b       strings.Builder
+	pending byte // Разделитель перед следующим текстом: ' ' или '\n'
+}
+
+func (w *pdfTextWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if w.pending != 0 && w.b.Len() > 0 {
+		w.b.WriteByte(w.pending)
+	}
+	w.pending = 0
+	w.b.WriteString(s)
+}
+
+func (w *pdfTextWriter) separator(c byte) {
+	if c == '\n' || w.pending == 0 {
+		w.pending = c
+	}
+}
+
+// Выполнение операторов текста потока содержимого. Формы (Do) разбираются рекурсивно.
+func (d *pdfDocument) showText(content []byte, resources pdfDict, w *pdfTextWriter, depth int) error {
+	if depth > pdfMaxDepth {
+		return errors.New("forms nested too deep")
+	}
+	fonts := d.dict(resources["Font"])
+	xobjects := d.dict(resources["XObject"])
+	cache := make(map[pdfName]*pdfFont)
+	var font *pdfFont
+	lastY := 0.0
+
+	l := &pdfLexer{data: content}
+	var operands []interface{}
+	for {
+		value, err := l.object(0)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		op, ok := value.(pdfOperator)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+		number := func(i int) float64 {
+			if i < len(operands) {
+				n, _ := operands[i].(float64)
+				return n
+			}
+			return 0
+		}
+		str := func(i int) string {
+			if i >= 0 && i < len(operands) && font != nil {
+				if s, ok := operands[i].(pdfString); ok {
+					return font.decode(s)
+				}
+			}
+			return ""
+		}
+
+		switch op {
+		case "ID":
+			// Данные встроенного изображения до EI
+			end := pdfInlineImageEnd.FindIndex(l.data[l.pos:])
+			if end == nil {
+				return errors.New("unterminated inline image")
+			}
+			l.pos += end[1]
+		case "Tf":
+			if len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					if cache[name] == nil {
+						cache[name] = d.font(d.dict(fonts[name]))
+					}
+					font = cache[name]
+				}
+			}
+		case "Tj":
+			w.text(str(0))
+		case "'":
+			w.separator('\n')
+			w.text(str(0))
+		case "\"":
+			w.separator('\n')
+			w.text(str(2))
+		case "TJ":
+			if len(operands) > 0 {
+				array, _ := operands[0].(pdfArray)
+				for _, item := range array {
+					switch v := item.(type) {
+					case pdfString:
+						if font != nil {
+							w.text(font.decode(v))
+						}
+					case float64:
+						// Большой сдвиг влево (в тысячных долях кегля) — пробел между словами
+						if v < -250 {
+							w.separator(' ')
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if number(1) != 0 {
+				w.separator('\n')
+			} else {
+				w.separator(' ')
+			}
+		case "T*":
+			w.separator('\n')
+		case "Tm":
+			if y := number(5); y != lastY {
+				w.separator('\n')
+				lastY = y
+			} else {
+				w.separator(' ')
+			}
+		case "ET":
+			w.separator(' ')
+		case "Do":
+			if len(operands) > 0 {
+				name, _ := operands[0].(pdfName)
+				form, ok := d.resolve(xobjects[name]).(pdfStream)
+				if ok && form.dict["Subtype"] == pdfName("Form") {
+					data, err := decodePDFStream(form)
+					if err != nil {
+						return err
+					}
+					formResources := d.dict(form.dict["Resources"])
+					if formResources == nil {
+						formResources = resources
+					}
+					if err := d.showText(data, formResources, w, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// Декодирование данных потока по фильтрам /Filter
+func decodePDFStream(stream pdfStream) ([]byte, error) {
+	data := stream.data
+	var filters []interface{}
+	switch f := stream.dict["Filter"].(type) {
+	case pdfName:
+		filters = []interface{}{f}
+	case pdfArray:
+		filters = f
+	}
+	for _, filter := range filters {
+		var err error
+		switch filter {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data)
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			l := &pdfLexer{data: append(append([]byte("<"), bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">"))...), '>')}
+			var s pdfString
+			s, err = l.hexString()
+			data = []byte(s)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", filter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding stream: %v", err)
+		}
+	}
+	if params := stream.dict["DecodeParms"]; params != nil {
+		if dict, ok := params.(pdfDict); ok {
+			if predictor, _ := dict["Predictor"].(float64); predictor > 1 {
+				return nil, fmt.Errorf("unsupported stream predictor %v", predictor)
+			}
+		}
+	}
+	return data, nil
+}
+
+// Распаковка FlateDecode. Данные, оборванные до конца потока zlib, возвращаются
+// в том объеме, который удалось распаковать.
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(zr)
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pdfFont переводит коды символов строк в текст
+type pdfFont struct {
+	codeLen   int               // Длина кода в байтах: 1 для простых шрифтов, обычно 2 для Type0
+	toUnicode map[string]string // Код → текст из ToUnicode
+	simple    bool              // Коды без ToUnicode переводятся как WinAnsiEncoding
+}
+
+// Шрифт по словарю /Font
+func (d *pdfDocument) font(dict pdfDict) *pdfFont {
+	f := &pdfFont{codeLen: 1, simple: true}
+	if dict == nil {
+		return f
+	}
+	if dict["Subtype"] == pdfName("Type0") {
+		f.codeLen, f.simple = 2, false
+	}
+	if stream, ok := d.resolve(dict["ToUnicode"]).(pdfStream); ok {
+		if data, err := decodePDFStream(stream); err == nil {
+			f.toUnicode, f.codeLen = parseToUnicode(data, f.codeLen)
+		}
+	}
+	return f
+}
+
+func (f *pdfFont) decode(s pdfString) string {
+	var b strings.Builder
+	for i := 0; i+f.codeLen <= len(s); i += f.codeLen {
+		code := string(s[i : i+f.codeLen])
+		if text, ok := f.toUnicode[code]; ok {
+			b.WriteString(text)
+		} else if f.simple {
+			b.WriteRune(winAnsiRune(code[0]))
+		}
+	}
+	return b.String()
+}
+
+// Символы WinAnsiEncoding в диапазоне 0x80–0x9F; остальные коды совпадают с Latin-1
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+	0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+	0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func winAnsiRune(c byte) rune {
+	if r, ok := winAnsiHigh[c]; ok {
+		return r
+	}
+	if c < 0x20 || 0x7F <= c && c < 0xA0 {
+		return ' '
+	}
+	return rune(c)
+}
+
+// Наибольший диапазон bfrange, который разворачивается в таблицу
+const maxCMapRange = 1 << 16
+
+// Разбор таблицы ToUnicode (секции codespacerange, bfchar и bfrange).
+// Возвращает таблицу кодов и длину кода.
+func parseToUnicode(data []byte, codeLen int) (map[string]string, int) {
+	table := make(map[string]string)
+	l := &pdfLexer{data: data}
+	var operands []interface{}
+	for {
+		value, err := l.object(0)
+		if err != nil {
+			break
+		}
+		op, ok := value.(pdfOperator)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			if len(operands) >= 2 {
+				if lo, ok := operands[0].(pdfString); ok && len(lo) > 0 {
+					codeLen = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					table[string(src)] = decodeUTF16(string(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				if end < start || end-start >= maxCMapRange {
+					continue
+				}
+				for code := start; code <= end; code++ {
+					key := codeBytes(code, len(lo))
+					switch dst := operands[i+2].(type) {
+					case pdfString:
+						table[key] = offsetText(decodeUTF16(string(dst)), int(code-start))
+					case pdfArray:
+						if n := int(code - start); n < len(dst) {
+							if s, ok := dst[n].(pdfString); ok {
+								table[key] = decodeUTF16(string(s))
+							}
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return table, codeLen
+}
+
+// Значение кода из байтов (старший байт первый)
+func codeValue(s pdfString) uint32 {
+	var v uint32
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | uint32(s[i])
+	}
+	return v
+}
+
+func codeBytes(v uint32, n int) string {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return string(b)
+}
+
+// Текст в UTF-16BE
+func decodeUTF16(s string) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// Текст с последним символом, сдвинутым на n (для диапазонов bfrange)
+func offsetText(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return text
+	}
+	runes[len(runes)-1] += rune(n)
+	return string(runes)
+}
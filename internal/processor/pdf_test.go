@@ -0,0 +1,173 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Сборка PDF из тел объектов с номерами 1..len(objects) и таблицей перекрестных ссылок
+func buildTestPDF(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// Поток, сжатый FlateDecode
+func flateStream(data string) string {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(data))
+	zw.Close()
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", b.Len(), b.Bytes())
+}
+
+// Таблица ToUnicode, в которой коды кириллицы совпадают с кодами Unicode
+const testToUnicode = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+1 beginbfchar
+<0003> <0020>
+endbfchar
+1 beginbfrange
+<0410> <044F> <0410>
+endbfrange
+endcmap`
+
+// Документ из страниц с потоками содержимого contents. Шрифт F1 — простой (WinAnsi),
+// F2 — Type0 с двухбайтовыми кодами и ToUnicode; ресурсы наследуются от дерева страниц.
+func testPDF(contents ...string) []byte {
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> >>", strings.Join(kids, " "), len(contents)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Arial /Encoding /Identity-H /ToUnicode 5 0 R >>",
+		flateStream(testToUnicode),
+	}
+	for i, content := range contents {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", 7+2*i),
+			content)
+	}
+	return buildTestPDF(objects)
+}
+
+func processPDF(t *testing.T, data []byte) (string, []string, error) {
+	t.Helper()
+	var logged []string
+	p := &PDFProcessor{Name: "test.pdf", Log: func(message string) { logged = append(logged, message) }}
+	rc, err := p.Process(bytes.NewReader(data))
+	if err != nil {
+		return "", logged, err
+	}
+	defer rc.Close()
+	text, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text), logged, nil
+}
+
+func TestPDFText(t *testing.T) {
+	data := testPDF(
+		flateStream("BT /F1 12 Tf 72 720 Td (Hello) Tj ( world) Tj 0 -14 Td [(Ker) -20 (ning) -400 (works)] TJ ET"),
+		flateStream("BT /F2 12 Tf 72 720 Td <041F04400438043204350442> Tj T* <041C04380440000304380020> Tj ET"),
+		"<< /Length 44 >>\nstream\nBT /F1 12 Tf (caf\\351 \\(1\\)) Tj (x) ' ET\nendstream",
+	)
+	text, logged, err := processPDF(t, data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	want := "Hello world\nKerning works\nПривет\nМир и\ncafé (1)\nx\n"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	if len(logged) != 0 {
+		t.Errorf("logged %q, want nothing", logged)
+	}
+}
+
+// Страница с поврежденным потоком пропускается, остальные извлекаются
+func TestPDFSkipsFailingPages(t *testing.T) {
+	broken := "<< /Length 9 /Filter /FlateDecode >>\nstream\nnot zlib!\nendstream"
+	data := testPDF(
+		flateStream("BT /F1 12 Tf (first) Tj ET"),
+		broken,
+		flateStream("BT /F1 12 Tf (third) Tj ET"),
+		"<< /Length 10 /Filter /LZWDecode >>\nstream\n0123456789\nendstream",
+	)
+	text, logged, err := processPDF(t, data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if text != "first\nthird\n" {
+		t.Errorf("text = %q, want %q", text, "first\nthird\n")
+	}
+	if len(logged) != 3 || !strings.HasPrefix(logged[0], "Skipped page 2 of test.pdf: ") ||
+		!strings.Contains(logged[1], "Skipped page 4 of test.pdf: unsupported stream filter LZWDecode") ||
+		logged[2] != "Skipped 2/4 pages in test.pdf" {
+		t.Errorf("logged %q", logged)
+	}
+
+	// Ошибка возвращается, только если не извлечена ни одна страница
+	if _, _, err := processPDF(t, testPDF(broken, broken)); err == nil || !strings.Contains(err.Error(), "no pages could be extracted (2 pages)") {
+		t.Errorf("Process of a PDF without readable pages: err = %v", err)
+	}
+}
+
+// Каталог и дерево страниц в потоке объектов (PDF 1.5), без trailer
+func TestPDFObjectStream(t *testing.T) {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"}
+	var header, body strings.Builder
+	for i, object := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(object + "\n")
+	}
+	packed := header.String() + body.String()
+	data := []byte("%PDF-1.5\n" +
+		"3 0 obj\n<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>\nendobj\n" +
+		"4 0 obj\n" + flateStream("BT /F1 12 Tf (packed) Tj ET") + "\nendobj\n" +
+		"5 0 obj\n<< /Type /Font /Subtype /Type1 >>\nendobj\n" +
+		"6 0 obj\n" + strings.Replace(flateStream(packed), "<<", fmt.Sprintf("<< /Type /ObjStm /N 2 /First %d", header.Len()), 1) + "\nendobj\n")
+
+	text, _, err := processPDF(t, data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if text != "packed\n" {
+		t.Errorf("text = %q, want %q", text, "packed\n")
+	}
+}
+
+func TestPDFInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"no header": "hello",
+		"no pages":  "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n",
+		"encrypted": "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n",
+	} {
+		if _, _, err := processPDF(t, []byte(data)); err == nil {
+			t.Errorf("%s: Process succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Processor извлекает из потока файла текст, пригодный для токенизации
+type Processor interface {
+	Process(r io.Reader) (io.ReadCloser, error)
+}
+
+// LineProcessor — процессор, каждая строка вывода которого — самостоятельный фрагмент
+// текста (ячейка, значение поля, абзац). Строка из одного слова учитывается целиком.
+type LineProcessor interface {
+	WholeLines() bool
+}
+
+// WholeLines сообщает, учитываются ли строки вывода процессора из одного слова
+func WholeLines(p Processor) bool {
+	lp, ok := p.(LineProcessor)
+	return ok && lp.WholeLines()
+}
+
+// Options задает параметры процессоров
+type Options struct {
+	// CSVColumn — столбец с текстом в .csv/.tsv файлах (индекс с нуля или имя из заголовка).
+	// Если не указан, такие файлы обрабатываются как обычный текст.
+	CSVColumn string
+	// JSONField — путь к текстовому полю в .jsonl/.ndjson файлах (например, .text или .meta.body).
+	// Если не указан, такие файлы обрабатываются как обычный текст.
+	JSONField string
+	// Code — обработка кода в .md/.markdown файлах: CodeKeep, CodeExclude или CodeSeparate
+	Code string
+	// CodeSink получает строки кода при Code == CodeSeparate. Вызывается из горутины
+	// процессора, все вызовы завершаются до конца потока текста.
+	CodeSink func(line string)
+	// MarkdownKeepURLs — сохранять адреса ссылок и изображений в .md/.markdown файлах
+	MarkdownKeepURLs bool
+	// OCR — распознавать страницы PDF без текстового слоя программами pdftoppm
+	// и tesseract (см. CheckOCR)
+	OCR bool
+	// OCRLang — языки распознавания tesseract, например rus+eng
+	OCRLang string
+	// Log получает сообщения процессоров о пропущенных данных
+	Log func(message string)
+}
+
+// Обработка кода (блоков и кода в строке) в документах с разметкой
+const (
+	CodeKeep     = ""         // Код учитывается как обычный текст
+	CodeExclude  = "exclude"  // Код пропускается
+	CodeSeparate = "separate" // Код не попадает в текст и передается в CodeSink
+)
+
+// NewProcessor выбирает процессор по имени файла
+func NewProcessor(name string, opts Options) Processor {
+	// Для .gz файлов формат определяем по имени без расширения
+	if strings.HasSuffix(name, ".gz") {
+		return &GzipProcessor{Inner: NewProcessor(strings.TrimSuffix(name, ".gz"), opts)}
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		if opts.CSVColumn != "" {
+			return &CSVProcessor{Comma: ',', Column: opts.CSVColumn}
+		}
+	case ".tsv":
+		if opts.CSVColumn != "" {
+			return &CSVProcessor{Comma: '\t', Column: opts.CSVColumn}
+		}
+	case ".jsonl", ".ndjson":
+		if opts.JSONField != "" {
+			return &JSONLProcessor{Field: opts.JSONField, Name: name, Log: opts.Log}
+		}
+	case ".docx":
+		return &DOCXProcessor{}
+	case ".pdf":
+		return &PDFProcessor{Name: name, OCR: opts.OCR, OCRLang: opts.OCRLang, Log: opts.Log}
+	case ".md", ".markdown":
+		return &MarkdownProcessor{Code: opts.Code, CodeSink: opts.CodeSink, KeepURLs: opts.MarkdownKeepURLs}
+	}
+
+	return &TextProcessor{}
+}
+
+// TextProcessor возвращает содержимое файла как есть
+type TextProcessor struct{}
+
+func (p *TextProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+// GzipProcessor распаковывает файл и передает его вложенному процессору
+type GzipProcessor struct {
+	Inner Processor
+}
+
+func (p *GzipProcessor) Process(r io.Reader) (io.ReadCloser, error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	inner, err := p.Inner.Process(gzReader)
+	if err != nil {
+		gzReader.Close()
+		return nil, err
+	}
+
+	return &multiCloser{Reader: inner, closers: []io.Closer{inner, gzReader}}, nil
+}
+
+func (p *GzipProcessor) WholeLines() bool {
+	return WholeLines(p.Inner)
+}
+
+// multiCloser закрывает несколько ресурсов по порядку
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,415 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Регион по умолчанию, если он не задан в окружении и конфигурации
+const DefaultRegion = "us-east-1"
+
+// Хеш тела запроса не вычисляется: тело запросов GET пустое, а S3 принимает неподписанное тело
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Credentials — ключи доступа AWS
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Временный токен (пустой для постоянных ключей)
+}
+
+// Client обращается к хранилищу S3 (или совместимому с ним) без AWS SDK:
+// перечисляет объекты по префиксу и подписывает запросы AWS Signature V4
+type Client struct {
+	Region      string
+	Endpoint    string // Адрес совместимого хранилища, например http://localhost:9000 (пустой — AWS S3 региона Region)
+	Credentials Credentials
+	HTTPClient  *http.Client // Клиент с подписью запросов
+}
+
+// NewClient создает клиент по стандартным настройкам AWS:
+// ключи — из AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY и AWS_SESSION_TOKEN или из профиля
+// AWS_PROFILE (default) файла ~/.aws/credentials; регион — из AWS_REGION, AWS_DEFAULT_REGION
+// или профиля ~/.aws/config; адрес совместимого хранилища — из AWS_ENDPOINT_URL_S3 или AWS_ENDPOINT_URL.
+//
+// В отличие от AWS SDK, другие источники ключей не поддерживаются: метаданные экземпляра EC2
+// (IMDS), роли задач ECS (AWS_CONTAINER_CREDENTIALS_*), роли сервисных аккаунтов EKS
+// (IRSA, AWS_WEB_IDENTITY_TOKEN_FILE), SSO и credential_process профиля. Для них временные
+// ключи нужно передать в переменных окружения.
+func NewClient() (*Client, error) {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		values, err := readProfile(sharedFile("AWS_SHARED_CREDENTIALS_FILE", "credentials"), profile)
+		if err != nil {
+			return nil, err
+		}
+		creds = Credentials{
+			AccessKeyID:     values["aws_access_key_id"],
+			SecretAccessKey: values["aws_secret_access_key"],
+			SessionToken:    values["aws_session_token"],
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		for _, env := range []string{"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI"} {
+			if os.Getenv(env) != "" {
+				return nil, fmt.Errorf("AWS credentials not found: %s is set, but web identity and container credentials are not supported (export temporary keys to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN)", env)
+			}
+		}
+		return nil, fmt.Errorf("AWS credentials not found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure profile %q)", profile)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		// В ~/.aws/config профили, кроме default, записываются как [profile name]
+		section := profile
+		if profile != "default" {
+			section = "profile " + profile
+		}
+		values, err := readProfile(sharedFile("AWS_CONFIG_FILE", "config"), section)
+		if err != nil {
+			return nil, err
+		}
+		region = values["region"]
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+
+	c := &Client{Region: region, Endpoint: strings.TrimSuffix(endpoint, "/"), Credentials: creds}
+	c.HTTPClient = &http.Client{Transport: &signingTransport{client: c, base: http.DefaultTransport}}
+	return c, nil
+}
+
+// ParseURI разбирает адрес вида s3://bucket/prefix
+func ParseURI(uri string) (bucket, prefix string, err error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid S3 address %q (expected s3://bucket/prefix)", uri)
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 address %q: bucket is empty", uri)
+	}
+	return bucket, prefix, nil
+}
+
+// ObjectURL возвращает адрес объекта для запроса GET. Для AWS S3 используется адрес
+// в домене корзины, для совместимых хранилищ — путь /bucket/key.
+func (c *Client) ObjectURL(bucket, key string) string {
+	return c.bucketURL(bucket) + "/" + escapePath(key)
+}
+
+// Адрес корзины. К совместимому хранилищу (Endpoint) запросы выполняются по пути
+// /bucket; адрес без схемы считается https. В AWS S3 используется домен корзины,
+// а для имен, которые не могут быть частью домена (с точками, например my.bucket,
+// не совпадающие с сертификатом *.s3.amazonaws.com), — путь /bucket в домене региона.
+func (c *Client) bucketURL(bucket string) string {
+	if endpoint := strings.TrimSuffix(c.Endpoint, "/"); endpoint != "" {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		return endpoint + "/" + escape(bucket)
+	}
+	if !virtualHostBucket(bucket) {
+		return fmt.Sprintf("https://s3.%s.amazonaws.com/%s", c.Region, escape(bucket))
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, c.Region)
+}
+
+// Может ли имя корзины быть частью домена: 3–63 строчные латинские буквы, цифры
+// и дефисы, без точек, с буквой или цифрой в начале и в конце
+func virtualHostBucket(bucket string) bool {
+	if len(bucket) < 3 || len(bucket) > 63 || bucket[0] == '-' || bucket[len(bucket)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(bucket); i++ {
+		c := bucket[i]
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Ответ ListObjectsV2
+type listResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// ListObjects возвращает ключи объектов корзины, начинающиеся с prefix.
+// Ключи, оканчивающиеся на "/" (метки каталогов), пропускаются.
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.bucketURL(bucket)+"/?"+encodeQuery(query), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error listing s3://%s/%s: %v", bucket, prefix, err)
+		}
+		var result listResult
+		err = decodeResponse(resp, &result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error listing s3://%s/%s: %v", bucket, prefix, err)
+		}
+
+		for _, object := range result.Contents {
+			if !strings.HasSuffix(object.Key, "/") {
+				keys = append(keys, object.Key)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Разбор XML-ответа; при статусе, отличном от 200, возвращается код ошибки S3
+func decodeResponse(resp *http.Response, v interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		var s3Err struct {
+			Code    string
+			Message string
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+			return fmt.Errorf("%s: %s: %s", resp.Status, s3Err.Code, s3Err.Message)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// signingTransport подписывает каждый запрос (в том числе повторный) AWS Signature V4
+type signingTransport struct {
+	client *Client
+	base   http.RoundTripper
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	t.client.sign(signed, time.Now().UTC())
+	return t.base.RoundTrip(signed)
+}
+
+// Подпись запроса AWS Signature V4 для сервиса s3
+func (c *Client) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	if c.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.Credentials.SessionToken)
+		headers["x-amz-security-token"] = c.Credentials.SessionToken
+	}
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	s := signRequest(c.Credentials.SecretAccessKey, c.Region, "s3", amzDate,
+		req.Method, canonicalURI, encodeQuery(req.URL.Query()), headers, unsignedPayload)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.Credentials.AccessKeyID, s.scope, s.signedHeaders, s.signature))
+}
+
+// Подпись AWS Signature V4
+type signature struct {
+	scope         string // Дата/регион/сервис/aws4_request
+	signedHeaders string // Имена подписанных заголовков через ";"
+	signature     string
+}
+
+// Вычисление подписи по каноническому запросу. headers — подписываемые заголовки
+// с именами в нижнем регистре, включая host; uri и query уже закодированы.
+func signRequest(secret, region, service, amzDate, method, uri, query string, headers map[string]string, payloadHash string) signature {
+	date := amzDate[:8]
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uri,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return signature{
+		scope:         scope,
+		signedHeaders: signedHeaders,
+		signature:     hex.EncodeToString(hmacSHA256(key, stringToSign)),
+	}
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// Кодирование строки запроса по правилам AWS: параметры по алфавиту, пробел — %20
+func encodeQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, escape(name)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Кодирование пути с сохранением разделителей "/"
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Кодирование URI по правилам AWS: без изменений остаются только A–Z, a–z, 0–9 и "-_.~"
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// Путь к общему файлу настроек AWS: из переменной окружения или в ~/.aws
+func sharedFile(env, name string) string {
+	if path := os.Getenv(env); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// Чтение значений раздела [section] INI-файла настроек AWS. Отсутствующий файл
+// или раздел не считается ошибкой.
+func readProfile(path, section string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if name, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,182 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Хеш пустого тела
+const emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Примеры из набора тестов AWS Signature V4 (aws-sig-v4-test-suite) и из документации
+// Amazon S3 "Signature Calculations for the Authorization Header"
+func TestSignRequestVectors(t *testing.T) {
+	const (
+		suiteSecret = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+		s3Secret    = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	)
+	tests := []struct {
+		name                                   string
+		secret, service, amzDate, uri, query   string
+		headers                                map[string]string
+		payloadHash, signedHeaders, wantSigned string
+	}{
+		{
+			name: "get-vanilla", secret: suiteSecret, service: "service", amzDate: "20150830T123600Z", uri: "/",
+			headers:     map[string]string{"host": "example.amazonaws.com", "x-amz-date": "20150830T123600Z"},
+			payloadHash: emptyPayload, signedHeaders: "host;x-amz-date",
+			wantSigned: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "get-vanilla-query-order-key-case", secret: suiteSecret, service: "service", amzDate: "20150830T123600Z", uri: "/",
+			query:       encodeQuery(url.Values{"Param2": {"value2"}, "Param1": {"value1"}}),
+			headers:     map[string]string{"host": "example.amazonaws.com", "x-amz-date": "20150830T123600Z"},
+			payloadHash: emptyPayload, signedHeaders: "host;x-amz-date",
+			wantSigned: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name: "s3-get-object", secret: s3Secret, service: "s3", amzDate: "20130524T000000Z", uri: "/test.txt",
+			headers: map[string]string{
+				"host": "examplebucket.s3.amazonaws.com", "range": "bytes=0-9",
+				"x-amz-content-sha256": emptyPayload, "x-amz-date": "20130524T000000Z",
+			},
+			payloadHash: emptyPayload, signedHeaders: "host;range;x-amz-content-sha256;x-amz-date",
+			wantSigned: "f0e8bdb87c964420e857bd35b5d6ed310bd44f0170aba48dd91039c6036bdb41",
+		},
+		{
+			name: "s3-list-objects", secret: s3Secret, service: "s3", amzDate: "20130524T000000Z", uri: "/",
+			query: encodeQuery(url.Values{"max-keys": {"2"}, "prefix": {"J"}}),
+			headers: map[string]string{
+				"host":                 "examplebucket.s3.amazonaws.com",
+				"x-amz-content-sha256": emptyPayload, "x-amz-date": "20130524T000000Z",
+			},
+			payloadHash: emptyPayload, signedHeaders: "host;x-amz-content-sha256;x-amz-date",
+			wantSigned: "34b48302e7b5fa45bde8084f4b7868a86f0a534bc59db6670ed5711ef69dc6f7",
+		},
+	}
+	for _, tt := range tests {
+		s := signRequest(tt.secret, "us-east-1", tt.service, tt.amzDate, "GET", tt.uri, tt.query, tt.headers, tt.payloadHash)
+		if wantScope := tt.amzDate[:8] + "/us-east-1/" + tt.service + "/aws4_request"; s.scope != wantScope {
+			t.Errorf("%s: scope = %q, want %q", tt.name, s.scope, wantScope)
+		}
+		if s.signedHeaders != tt.signedHeaders {
+			t.Errorf("%s: signed headers = %q, want %q", tt.name, s.signedHeaders, tt.signedHeaders)
+		}
+		if s.signature != tt.wantSigned {
+			t.Errorf("%s: signature = %s, want %s", tt.name, s.signature, tt.wantSigned)
+		}
+	}
+}
+
+// Заголовки подписи запроса клиента
+func TestSign(t *testing.T) {
+	c := &Client{Region: "eu-west-1", Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}}
+	req := httptest.NewRequest("GET", "https://bucket.s3.eu-west-1.amazonaws.com/?list-type=2&prefix=a%20b", nil)
+	c.sign(req, time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC))
+
+	if got := req.Header.Get("X-Amz-Date"); got != "20250314T150926Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q", got)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKID/20250314/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, want) || len(got) != len(want)+64 {
+		t.Errorf("Authorization = %q, want %q followed by a signature", got, want)
+	}
+}
+
+// Перечисление с продолжением и ключами, которые нужно кодировать в адресе
+func TestListObjects(t *testing.T) {
+	pages := map[string][]string{
+		"":           {"texts/", "texts/a b.txt", "texts/тест+1.txt"},
+		"next/page=": {"texts/dir/c&d.txt.gz", "texts/100%.md"},
+	}
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			http.Error(w, "unsigned request", http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("list-type") == "" {
+			// Запрос объекта: ключ передается в пути после имени корзины
+			fmt.Fprint(w, strings.TrimPrefix(r.URL.Path, "/my-bucket/"))
+			return
+		}
+		query := r.URL.Query()
+		if r.URL.Path != "/my-bucket/" || query.Get("list-type") != "2" || query.Get("prefix") != "texts/" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		token := query.Get("continuation-token")
+		tokens = append(tokens, token)
+		keys, ok := pages[token]
+		if !ok {
+			http.Error(w, "<Error><Code>InvalidArgument</Code><Message>bad token</Message></Error>", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult>`)
+		for _, key := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", strings.ReplaceAll(key, "&", "&amp;"))
+		}
+		if token == "" {
+			fmt.Fprint(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>next/page=</NextContinuationToken>")
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	}))
+	defer srv.Close()
+
+	c := &Client{Region: DefaultRegion, Endpoint: srv.URL, Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}}
+	c.HTTPClient = &http.Client{Transport: &signingTransport{client: c, base: http.DefaultTransport}}
+	keys, err := c.ListObjects(context.Background(), "my-bucket", "texts/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	want := []string{"texts/a b.txt", "texts/тест+1.txt", "texts/dir/c&d.txt.gz", "texts/100%.md"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+	if !slices.Equal(tokens, []string{"", "next/page="}) {
+		t.Errorf("continuation tokens = %q", tokens)
+	}
+
+	// Адрес объекта кодирует ключ, и хранилище получает его без изменений
+	if got, want := c.ObjectURL("my-bucket", "texts/a b.txt"), srv.URL+"/my-bucket/texts/a%20b.txt"; got != want {
+		t.Errorf("ObjectURL = %q, want %q", got, want)
+	}
+	for _, key := range keys {
+		resp, err := c.HTTPClient.Get(c.ObjectURL("my-bucket", key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != key {
+			t.Errorf("GET %q: %s %q", key, resp.Status, body)
+		}
+	}
+
+	if _, err := c.ListObjects(context.Background(), "other", "texts/"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("ListObjects of an unexpected bucket: err = %v, want a 400 error", err)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	bucket, prefix, err := ParseURI("s3://my-bucket/texts/2025/")
+	if err != nil || bucket != "my-bucket" || prefix != "texts/2025/" {
+		t.Errorf("ParseURI = %q, %q, %v", bucket, prefix, err)
+	}
+	for _, uri := range []string{"my-bucket/texts", "s3:///texts"} {
+		if _, _, err := ParseURI(uri); err == nil {
+			t.Errorf("ParseURI(%q) succeeded, want an error", uri)
+		}
+	}
+}
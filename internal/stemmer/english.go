@@ -0,0 +1,253 @@
+package stemmer
+
+import "strings"
+
+// Исключения английского стеммера Snowball (Porter2)
+var (
+	enExceptions = map[string]string{
+		"skis": "ski", "skies": "sky", "dying": "die", "lying": "lie", "tying": "tie",
+		"idly": "idl", "gently": "gentl", "ugly": "ugli", "early": "earli", "only": "onli", "singly": "singl",
+		"sky": "sky", "news": "news", "howe": "howe", "atlas": "atlas", "cosmos": "cosmos", "bias": "bias", "andes": "andes",
+	}
+	enExceptionsAfter1a = map[string]bool{
+		"inning": true, "outing": true, "canning": true, "herring": true,
+		"earring": true, "proceed": true, "exceed": true, "succeed": true,
+	}
+	enStep2 = map[string]string{
+		"tional": "tion", "enci": "ence", "anci": "ance", "abli": "able", "entli": "ent",
+		"izer": "ize", "ization": "ize", "ational": "ate", "ation": "ate", "ator": "ate",
+		"alism": "al", "aliti": "al", "alli": "al", "fulness": "ful", "ousli": "ous", "ousness": "ous",
+		"iveness": "ive", "iviti": "ive", "biliti": "ble", "bli": "ble", "ogi": "og",
+		"fulli": "ful", "lessli": "less", "li": "",
+	}
+	enStep3 = map[string]string{
+		"tional": "tion", "ational": "ate", "alize": "al", "icate": "ic", "iciti": "ic",
+		"ical": "ic", "ful": "", "ness": "", "ative": "",
+	}
+	enStep4 = []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+		"ent", "ism", "ate", "iti", "ous", "ive", "ize", "ion",
+	}
+)
+
+func isEnglishVowel(c byte) bool {
+	return strings.IndexByte("aeiouy", c) >= 0
+}
+
+// English — английский стеммер Snowball (Porter2). Слова должны быть в нижнем регистре.
+func English(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			if word[i] != '\'' {
+				return word // Стеммер работает только с латиницей
+			}
+		}
+	}
+
+	w := []byte(strings.TrimPrefix(word, "'"))
+	if stem, ok := enExceptions[string(w)]; ok {
+		return stem
+	}
+
+	// Начальная y и y после гласной считаются согласными
+	for i := range w {
+		if w[i] == 'y' && (i == 0 || isEnglishVowel(w[i-1])) {
+			w[i] = 'Y'
+		}
+	}
+
+	r1 := len(w)
+	switch {
+	case strings.HasPrefix(string(w), "gener"), strings.HasPrefix(string(w), "arsen"):
+		r1 = 5
+	case strings.HasPrefix(string(w), "commun"):
+		r1 = 6
+	default:
+		r1 = enRegionAfter(w, 0)
+	}
+	r2 := enRegionAfter(w, r1)
+
+	// Шаг 0
+	for _, suffix := range []string{"'s'", "'s", "'"} {
+		if hasSuffix(w, suffix) {
+			w = w[:len(w)-len(suffix)]
+			break
+		}
+	}
+
+	// Шаг 1a
+	switch {
+	case hasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case hasSuffix(w, "ied"), hasSuffix(w, "ies"):
+		if len(w) > 4 {
+			w = w[:len(w)-2]
+		} else {
+			w = w[:len(w)-1]
+		}
+	case hasSuffix(w, "us"), hasSuffix(w, "ss"):
+	case hasSuffix(w, "s"):
+		for i := 0; i < len(w)-2; i++ {
+			if isEnglishVowel(w[i]) {
+				w = w[:len(w)-1]
+				break
+			}
+		}
+	}
+
+	if enExceptionsAfter1a[string(w)] {
+		return string(w)
+	}
+
+	// Шаг 1b
+	switch suffix := enLongest(w, []string{"eed", "eedly", "ed", "edly", "ing", "ingly"}); suffix {
+	case "eed", "eedly":
+		if len(w)-len(suffix) >= r1 {
+			w = append(w[:len(w)-len(suffix)], "ee"...)
+		}
+	case "ed", "edly", "ing", "ingly":
+		stem := w[:len(w)-len(suffix)]
+		if !containsVowel(stem) {
+			break
+		}
+		w = stem
+		switch {
+		case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+			w = append(w, 'e')
+		case enDouble(w):
+			w = w[:len(w)-1]
+		case enShortWord(w, r1):
+			w = append(w, 'e')
+		}
+	}
+
+	// Шаг 1c
+	if n := len(w); n > 2 && (w[n-1] == 'y' || w[n-1] == 'Y') && !isEnglishVowel(w[n-2]) {
+		w[n-1] = 'i'
+	}
+
+	// Шаг 2
+	if suffix := enLongestKey(w, enStep2); suffix != "" && len(w)-len(suffix) >= r1 {
+		stem := w[:len(w)-len(suffix)]
+		switch suffix {
+		case "ogi":
+			if hasSuffix(stem, "l") {
+				w = append(stem, "og"...)
+			}
+		case "li":
+			if len(stem) > 0 && strings.IndexByte("cdeghkmnrt", stem[len(stem)-1]) >= 0 {
+				w = stem
+			}
+		default:
+			w = append(stem, enStep2[suffix]...)
+		}
+	}
+
+	// Шаг 3
+	if suffix := enLongestKey(w, enStep3); suffix != "" && len(w)-len(suffix) >= r1 {
+		if suffix != "ative" || len(w)-len(suffix) >= r2 {
+			w = append(w[:len(w)-len(suffix)], enStep3[suffix]...)
+		}
+	}
+
+	// Шаг 4
+	if suffix := enLongest(w, enStep4); suffix != "" && len(w)-len(suffix) >= r2 {
+		stem := w[:len(w)-len(suffix)]
+		if suffix != "ion" || hasSuffix(stem, "s") || hasSuffix(stem, "t") {
+			w = stem
+		}
+	}
+
+	// Шаг 5
+	if n := len(w); n > 0 {
+		switch w[n-1] {
+		case 'e':
+			if n-1 >= r2 || (n-1 >= r1 && !enShortSyllable(w[:n-1])) {
+				w = w[:n-1]
+			}
+		case 'l':
+			if n-1 >= r2 && n > 1 && w[n-2] == 'l' {
+				w = w[:n-1]
+			}
+		}
+	}
+
+	return strings.ReplaceAll(string(w), "Y", "y")
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+func containsVowel(w []byte) bool {
+	for _, c := range w {
+		if isEnglishVowel(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Самое длинное окончание из списка
+func enLongest(w []byte, suffixes []string) string {
+	found := ""
+	for _, suffix := range suffixes {
+		if len(suffix) > len(found) && hasSuffix(w, suffix) {
+			found = suffix
+		}
+	}
+	return found
+}
+
+// Самое длинное окончание среди ключей таблицы замен
+func enLongestKey(w []byte, table map[string]string) string {
+	found := ""
+	for suffix := range table {
+		if len(suffix) > len(found) && hasSuffix(w, suffix) {
+			found = suffix
+		}
+	}
+	return found
+}
+
+// Область R: после первой согласной, следующей за гласной, начиная с позиции start
+func enRegionAfter(w []byte, start int) int {
+	for i := start + 1; i < len(w); i++ {
+		if !isEnglishVowel(w[i]) && isEnglishVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// Окончание на удвоенную согласную
+func enDouble(w []byte) bool {
+	for _, double := range []string{"bb", "dd", "ff", "gg", "mm", "nn", "pp", "rr", "tt"} {
+		if hasSuffix(w, double) {
+			return true
+		}
+	}
+	return false
+}
+
+// Короткий слог в конце слова: согласная-гласная-согласная (кроме w, x, Y)
+// или гласная-согласная в начале слова
+func enShortSyllable(w []byte) bool {
+	n := len(w)
+	if n == 2 {
+		return isEnglishVowel(w[0]) && !isEnglishVowel(w[1])
+	}
+	if n < 3 {
+		return false
+	}
+	return !isEnglishVowel(w[n-3]) && isEnglishVowel(w[n-2]) && !isEnglishVowel(w[n-1]) &&
+		w[n-1] != 'w' && w[n-1] != 'x' && w[n-1] != 'Y'
+}
+
+// Короткое слово: оканчивается коротким слогом и область R1 пуста
+func enShortWord(w []byte, r1 int) bool {
+	return r1 >= len(w) && enShortSyllable(w)
+}
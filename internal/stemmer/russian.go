@@ -0,0 +1,115 @@
+package stemmer
+
+import "strings"
+
+// Окончания русского стеммера Snowball.
+// Окончания первой группы должны следовать за "а" или "я".
+var (
+	ruPerfectiveGerund1 = []string{"в", "вши", "вшись"}
+	ruPerfectiveGerund2 = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	ruAdjective         = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	ruParticiple1       = []string{"ем", "нн", "вш", "ющ", "щ"}
+	ruParticiple2       = []string{"ивш", "ывш", "ующ"}
+	ruReflexive         = []string{"ся", "сь"}
+	ruVerb1             = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	ruVerb2             = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+	ruNoun              = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й", "иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+	ruDerivational      = []string{"ост", "ость"}
+	ruTidyUp            = []string{"ейш", "ейше", "н", "ь"}
+)
+
+func isRussianVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// Russian — русский стеммер Snowball
+func Russian(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+
+	// RV — область после первой гласной, R2 — вторая область R
+	rv := len(w)
+	for i, r := range w {
+		if isRussianVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	r2 := regionAfter(w, regionAfter(w, 0))
+
+	// Шаг 1
+	if suffix, ok := ruGroupSuffix(w, rv, ruPerfectiveGerund1, ruPerfectiveGerund2); ok {
+		w = w[:len(w)-len([]rune(suffix))]
+	} else {
+		if suffix := longestSuffix(w, rv, ruReflexive); suffix != "" {
+			w = w[:len(w)-len([]rune(suffix))]
+		}
+
+		if suffix := longestSuffix(w, rv, ruAdjective); suffix != "" {
+			// Прилагательное, возможно с причастием перед окончанием
+			w = w[:len(w)-len([]rune(suffix))]
+			if suffix, ok := ruGroupSuffix(w, rv, ruParticiple1, ruParticiple2); ok {
+				w = w[:len(w)-len([]rune(suffix))]
+			}
+		} else if suffix, ok := ruGroupSuffix(w, rv, ruVerb1, ruVerb2); ok {
+			w = w[:len(w)-len([]rune(suffix))]
+		} else if suffix := longestSuffix(w, rv, ruNoun); suffix != "" {
+			w = w[:len(w)-len([]rune(suffix))]
+		}
+	}
+
+	// Шаг 2
+	if len(w) > rv && w[len(w)-1] == 'и' {
+		w = w[:len(w)-1]
+	}
+
+	// Шаг 3: словообразовательное окончание в R2
+	if suffix := longestSuffix(w, rv, ruDerivational); suffix != "" && len(w)-len([]rune(suffix)) >= r2 {
+		w = w[:len(w)-len([]rune(suffix))]
+	}
+
+	// Шаг 4
+	switch suffix := longestSuffix(w, rv, ruTidyUp); suffix {
+	case "ейш", "ейше":
+		w = w[:len(w)-len([]rune(suffix))]
+		if len(w)-2 >= rv && string(w[len(w)-2:]) == "нн" {
+			w = w[:len(w)-1]
+		}
+	case "н":
+		if len(w)-2 >= rv && w[len(w)-2] == 'н' {
+			w = w[:len(w)-1]
+		}
+	case "ь":
+		w = w[:len(w)-1]
+	}
+
+	return string(w)
+}
+
+// Поиск окончания из двух групп. Окончания первой группы должны следовать за "а" или "я",
+// которые остаются в основе.
+func ruGroupSuffix(w []rune, rv int, group1, group2 []string) (string, bool) {
+	suffix := longestSuffix(w, rv, append(append([]string{}, group1...), group2...))
+	if suffix == "" {
+		return "", false
+	}
+	for _, s := range group1 {
+		if s != suffix {
+			continue
+		}
+		i := len(w) - len([]rune(suffix)) - 1
+		if i < rv || (w[i] != 'а' && w[i] != 'я') {
+			return "", false
+		}
+	}
+	return suffix, true
+}
+
+// Область R: после первой негласной, следующей за гласной, начиная с позиции start
+func regionAfter(w []rune, start int) int {
+	for i := start + 1; i < len(w); i++ {
+		if !isRussianVowel(w[i]) && isRussianVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
@@ -0,0 +1,35 @@
+package stemmer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stemmer приводит слово к основе
+type Stemmer func(word string) string
+
+// New возвращает стеммер Snowball для указанного языка (russian/ru или english/en)
+func New(lang string) (Stemmer, error) {
+	switch strings.ToLower(lang) {
+	case "russian", "ru":
+		return Russian, nil
+	case "english", "en":
+		return English, nil
+	}
+	return nil, fmt.Errorf("unsupported stemmer language %q", lang)
+}
+
+// Поиск самого длинного окончания из списка, целиком лежащего в области [start:]
+func longestSuffix(word []rune, start int, suffixes []string) string {
+	found := ""
+	for _, suffix := range suffixes {
+		n := len([]rune(suffix))
+		if n <= len([]rune(found)) || len(word)-n < start {
+			continue
+		}
+		if string(word[len(word)-n:]) == suffix {
+			found = suffix
+		}
+	}
+	return found
+}
@@ -0,0 +1,56 @@
+package stemmer
+
+import "testing"
+
+// Пары слово — основа из эталонных файлов voc.txt и output.txt алгоритмов Snowball
+func TestEnglish(t *testing.T) {
+	tests := map[string]string{
+		"consign":     "consign",
+		"consigned":   "consign",
+		"consistency": "consist",
+		"consolatory": "consolatori",
+		"conspicuous": "conspicu",
+		"conspiracy":  "conspiraci",
+		"constable":   "constabl",
+		"knackeries":  "knackeri",
+		"kneeling":    "kneel",
+		"knightly":    "knight",
+		"knitting":    "knit",
+		"knives":      "knive",
+		"skies":       "sky",
+		"dying":       "die",
+		"news":        "news",
+		"gently":      "gentl",
+		"early":       "earli",
+		"only":        "onli",
+	}
+	for word, want := range tests {
+		if got := English(word); got != want {
+			t.Errorf("English(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestRussian(t *testing.T) {
+	tests := map[string]string{
+		"вавиловка":    "вавиловк",
+		"вагона":       "вагон",
+		"важная":       "важн",
+		"важнейшими":   "важн",
+		"важничал":     "важнича",
+		"валандался":   "валанда",
+		"валериановых": "валерианов",
+		"валерию":      "валер",
+		"валетами":     "валет",
+		"валился":      "вал",
+		"вальсишку":    "вальсишк",
+		"валяется":     "валя",
+		"валять":       "валя",
+		"вами":         "вам",
+	}
+	for word, want := range tests {
+		if got := Russian(word); got != want {
+			t.Errorf("Russian(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
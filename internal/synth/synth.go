@@ -0,0 +1,101 @@
+// Package synth генерирует синтетические корпуса с распределением токенов по закону Ципфа
+// для воспроизводимых замеров производительности.
+package synth
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options задает размер и распределение корпуса
+type Options struct {
+	Files        int     // Число файлов
+	LinesPerFile int     // Число строк в файле
+	WordsPerLine int     // Число слов в строке
+	VocabSize    int     // Число различных слов
+	Exponent     float64 // Показатель закона Ципфа (больше 1)
+	Seed         int64   // Начальное значение генератора; одинаковое значение дает одинаковый корпус
+}
+
+// Буквы, из которых составляются слова
+var alphabet = []rune("абвгдеёжзийклмнопрстуфхцчшщъыьэюя")
+
+// Знаки препинания, которыми иногда заканчиваются слова
+var punctuation = []string{",", ".", "!", "?", ";", ":"}
+
+// Generate записывает корпус в директорию dir (файлы corpus_00000.txt и т.д.)
+func Generate(dir string, opts Options) error {
+	if opts.Files <= 0 || opts.LinesPerFile <= 0 || opts.WordsPerLine <= 0 || opts.VocabSize <= 1 {
+		return fmt.Errorf("invalid corpus size")
+	}
+	if opts.Exponent <= 1 {
+		return fmt.Errorf("zipf exponent must be greater than 1")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating corpus directory: %v", err)
+	}
+
+	r := rand.New(rand.NewSource(opts.Seed))
+	words := makeWords(r, opts.VocabSize)
+	zipf := rand.NewZipf(r, opts.Exponent, 1, uint64(opts.VocabSize-1))
+
+	for i := 0; i < opts.Files; i++ {
+		filePath := filepath.Join(dir, fmt.Sprintf("corpus_%05d.txt", i))
+		if err := writeFile(filePath, r, zipf, words, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Генерация различных слов; слово с индексом i имеет ранг i+1
+func makeWords(r *rand.Rand, n int) []string {
+	words := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for len(words) < n {
+		// Частые слова короче редких
+		length := 2 + len(words)*8/n + r.Intn(4)
+		var b strings.Builder
+		for j := 0; j < length; j++ {
+			b.WriteRune(alphabet[r.Intn(len(alphabet))])
+		}
+		word := b.String()
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		words = append(words, word)
+	}
+	return words
+}
+
+// Запись одного файла корпуса
+func writeFile(filePath string, r *rand.Rand, zipf *rand.Zipf, words []string, opts Options) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating file %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for line := 0; line < opts.LinesPerFile; line++ {
+		for w := 0; w < opts.WordsPerLine; w++ {
+			if w > 0 {
+				writer.WriteByte(' ')
+			}
+			writer.WriteString(words[zipf.Uint64()])
+			if r.Intn(10) == 0 {
+				writer.WriteString(punctuation[r.Intn(len(punctuation))])
+			}
+		}
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing file %s: %v", filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Файл, который записывается во временный файл в той же директории и заменяет
+// целевой файл только после успешного завершения записи
+type atomicFile struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// Создание временного файла рядом с целевым
+func createAtomic(path string) (*atomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &atomicFile{File: file, path: path}, nil
+}
+
+// Сброс данных на диск и переименование временного файла в целевой
+func (f *atomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return fmt.Errorf("error syncing file: %v", err)
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("error closing file: %v", err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("error renaming file: %v", err)
+	}
+	f.committed = true
+	return nil
+}
+
+// Удаление временного файла, если запись не была завершена
+func (f *atomicFile) Abort() {
+	if f.committed {
+		return
+	}
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// Имя выходного файла, означающее стандартный вывод
+const StdoutName = "-"
+
+// Буферизованный вывод словаря: в атомарно записываемый файл или в stdout для "-"
+type outputWriter struct {
+	*bufio.Writer
+	file   *atomicFile
+	device *os.File // Открытый напрямую файл, не являющийся обычным
+}
+
+// Создание вывода словаря
+func createOutput(path string) (*outputWriter, error) {
+	switch path {
+	case StdoutName, "/dev/stdout":
+		return &outputWriter{Writer: bufio.NewWriter(os.Stdout)}, nil
+	case "/dev/stderr":
+		return &outputWriter{Writer: bufio.NewWriter(os.Stderr)}, nil
+	}
+	// Для символической ссылки заменяется файл, на который она указывает, а не сама ссылка
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	// Устройства и каналы (например, /dev/null) нельзя заменить переименованием
+	if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &outputWriter{Writer: bufio.NewWriter(file), device: file}, nil
+	}
+	file, err := createAtomic(path)
+	if err != nil {
+		return nil, err
+	}
+	return &outputWriter{Writer: bufio.NewWriter(file), file: file}, nil
+}
+
+// Сброс буфера и, для файла, замена целевого файла записанным
+func (w *outputWriter) Commit() error {
+	if err := w.Flush(); err != nil {
+		w.Abort()
+		return fmt.Errorf("error writing output: %v", err)
+	}
+	if w.file != nil {
+		return w.file.Commit()
+	}
+	if w.device != nil {
+		return w.device.Close()
+	}
+	return nil
+}
+
+// Отмена записи в файл; данные, уже выведенные в stdout, не отменяются
+func (w *outputWriter) Abort() {
+	if w.file != nil {
+		w.file.Abort()
+	}
+	if w.device != nil {
+		w.device.Close()
+	}
+}
@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/terratensor/vocab/internal/synth"
+)
+
+// Корпус для замеров: фиксированное начальное значение дает одинаковые файлы
+// при каждом запуске, поэтому результаты разных версий сравнимы
+var benchCorpus = synth.Options{
+	Files:        32,
+	LinesPerFile: 500,
+	WordsPerLine: 12,
+	VocabSize:    20000,
+	Exponent:     1.1,
+	Seed:         1,
+}
+
+func BenchmarkBuildVocabulary(b *testing.B) {
+	dir := b.TempDir()
+	if err := synth.Generate(dir, benchCorpus); err != nil {
+		b.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var size int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size += info.Size()
+	}
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			tok, err := NewTokenizer(Options{Lowercase: true, FilterPunct: true, MaxGoroutines: workers, ErrorMode: ErrorModeNone, Quiet: true})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer tok.Close()
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := tok.BuildVocabulary(dir, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
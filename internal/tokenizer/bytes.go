@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Токен байта при побайтовом подсчете (ByteLevel): "<0x41>" для байта 0x41.
+// Всегда две шестнадцатеричные цифры в верхнем регистре, поэтому запись однозначна,
+// не содержит пробельных символов и обращается функцией ParseByteToken.
+func byteToken(b byte) string {
+	return fmt.Sprintf("<0x%02X>", b)
+}
+
+// ParseByteToken восстанавливает байт по токену вида "<0x41>".
+// Возвращает false, если токен не является токеном байта.
+func ParseByteToken(token string) (byte, bool) {
+	if len(token) != 6 || token[:3] != "<0x" || token[5] != '>' {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(token[3:5], 16, 8)
+	if err != nil || byteToken(byte(value)) != token {
+		return 0, false
+	}
+	return byte(value), true
+}
+
+// Подсчет частот отдельных байтов текста (0–255, включая переводы строк) без токенизации.
+// Фильтры и преобразования токенов не применяются.
+func (t *Tokenizer) scanBytes(ctx context.Context, reader io.Reader, result *fileResult) error {
+	var counts [256]int
+	buf := make([]byte, 64*1024)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := reader.Read(buf)
+		for _, b := range buf[:n] {
+			counts[b]++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for b, count := range counts {
+		if count > 0 {
+			result.vocab[byteToken(byte(b))] += count
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package tokenizer
+
+import "strings"
+
+// Объединение токенов, отличающихся только регистром.
+// Счетчики суммируются, представителем группы становится самое частое написание
+// (при равенстве — первое по алфавиту).
+func foldCase[C countValue](vocab map[string]C) map[string]C {
+	type group struct {
+		total C
+		best  string
+	}
+	groups := make(map[string]*group)
+	for token, count := range vocab {
+		key := strings.ToLower(token)
+		g, ok := groups[key]
+		if !ok {
+			groups[key] = &group{total: count, best: token}
+			continue
+		}
+		g.total += count
+		if bestCount := vocab[g.best]; count > bestCount || (count == bestCount && token < g.best) {
+			g.best = token
+		}
+	}
+
+	folded := make(map[string]C, len(groups))
+	for _, g := range groups {
+		folded[g.best] = g.total
+	}
+	return folded
+}
@@ -0,0 +1,26 @@
+package tokenizer
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// Представителем группы становится самое частое написание, даже если оно с заглавной буквы,
+// а счетчики всех написаний суммируются
+func TestFoldCaseMajorityWins(t *testing.T) {
+	vocab := map[string]int{"Москва": 7, "москва": 2, "МОСКВА": 1, "кот": 3, "Кот": 1, "Ель": 2, "ель": 2}
+	want := map[string]int{"Москва": 10, "кот": 4, "Ель": 4}
+	if got := foldCase(vocab); !maps.Equal(got, want) {
+		t.Errorf("foldCase = %v, want %v", got, want)
+	}
+
+	tok := newTestTokenizer(t, Options{FoldCase: true, FilterPunct: true})
+	got, err := tok.BuildReaderVocabulary(strings.NewReader("Москва. Москва и москва, Москва!"), "text.txt")
+	if err != nil {
+		t.Fatalf("BuildReaderVocabulary: %v", err)
+	}
+	if got["Москва"] != 4 || got["москва"] != 0 {
+		t.Errorf("BuildReaderVocabulary = %v, want Москва counted 4 times", got)
+	}
+}
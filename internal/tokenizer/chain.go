@@ -0,0 +1,153 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenFilter преобразует токен перед подсчетом. Возвращает false, если токен нужно отбросить.
+// Фильтры вызываются из нескольких горутин одновременно и должны быть безопасны для этого.
+type TokenFilter func(token string) (string, bool)
+
+// Цепочка встроенных преобразований, включенных параметрами. Порядок фиксирован:
+// удаление пробелов, обрезка пунктуации по краям, нижний регистр, фильтрация пунктуации,
+// замена чисел токеном NumToken, отбрасывание токенов с цифрами и подчеркиваниями, фильтрация по алфавиту, определение части речи,
+// лемматизация, стемминг, проверка по словарю, белый и черный списки.
+func (t *Tokenizer) builtinFilters() []TokenFilter {
+	var filters []TokenFilter
+
+	// Удаление пробелов, в том числе неразрывных и нулевой ширины
+	if t.opts.NormalizeWhitespace {
+		filters = append(filters, func(token string) (string, bool) {
+			// ZWJ внутри эмодзи соединяет части последовательности и не удаляется
+			if t.opts.KeepEmoji && isEmojiToken(token) {
+				return token, true
+			}
+			token = normalizeWhitespace(token)
+			return token, token != ""
+		})
+	}
+
+	// Обрезка пунктуации по краям токена ("(слово)," -> "слово")
+	if t.opts.TrimPunct {
+		filters = append(filters, func(token string) (string, bool) {
+			token = strings.TrimFunc(token, unicode.IsPunct)
+			return token, token != ""
+		})
+	}
+
+	// Приведение к нижнему регистру
+	if t.opts.Lowercase {
+		filters = append(filters, func(token string) (string, bool) {
+			return strings.ToLower(token), true
+		})
+	}
+
+	// Фильтрация пунктуации
+	if t.opts.FilterPunct {
+		isPunct := isPunctuation
+		if t.punct != nil {
+			isPunct = t.punct.isPunctuation
+		}
+		filters = append(filters, func(token string) (string, bool) {
+			if t.opts.KeepEmoji && isEmojiToken(token) {
+				return token, true
+			}
+			return token, !isPunct(token)
+		})
+	}
+
+	// Замена чисел ("2019", "3", "1,000") одним токеном; частоты чисел суммируются.
+	// Токен-заместитель не содержит цифр и не отбрасывается следующими фильтрами цифр
+	if t.opts.NumToken != "" {
+		filters = append(filters, func(token string) (string, bool) {
+			if isNumber(token) {
+				return t.opts.NumToken, true
+			}
+			return token, true
+		})
+	}
+
+	// Отбрасывание токенов с цифрами ("covid19", "2020s", "42")
+	if t.opts.DropDigitTokens {
+		filters = append(filters, func(token string) (string, bool) {
+			return token, strings.IndexFunc(token, unicode.IsDigit) < 0
+		})
+	}
+
+	// Отбрасывание токенов с подчеркиваниями ("snake_case")
+	if t.opts.DropUnderscoreTokens {
+		filters = append(filters, func(token string) (string, bool) {
+			return token, !strings.Contains(token, "_")
+		})
+	}
+
+	// Фильтрация по алфавиту (токен-заместитель чисел проходит независимо от своих букв)
+	if t.script != nil {
+		filters = append(filters, func(token string) (string, bool) {
+			return token, token == t.opts.NumToken || t.inScript(token)
+		})
+	}
+
+	// Приведение к лемме
+	var forms []TokenFilter
+	if t.lemmas != nil {
+		forms = append(forms, func(token string) (string, bool) {
+			return t.lemmatize(token), true
+		})
+	}
+
+	// Приведение к основе (токены не из букв не изменяются)
+	if t.stem != nil {
+		forms = append(forms, func(token string) (string, bool) {
+			if isWord(token) {
+				token = t.stem(token)
+			}
+			return token, true
+		})
+	}
+
+	// Часть речи определяется по словоформе, поэтому лемматизация и стемминг
+	// выполняются внутри ее фильтра, после определения тега
+	if t.posTags != nil {
+		forms = []TokenFilter{t.posFilter(forms)}
+	}
+	filters = append(filters, forms...)
+
+	// Учет только слов из словаря (DictionaryFile). Проверяется токен после всех
+	// преобразований, поэтому словарь сопоставляется с леммами и основами, если они включены
+	if t.dictionary != nil {
+		filters = append(filters, func(token string) (string, bool) {
+			_, ok := t.dictionary[token]
+			return token, ok
+		})
+	}
+
+	// Белый и черный списки (WhitelistFile, BlacklistFile) при любом способе построения словаря
+	if t.whitelist != nil || t.blacklist != nil {
+		filters = append(filters, func(token string) (string, bool) {
+			return token, t.listed(token)
+		})
+	}
+
+	return filters
+}
+
+// AddFilter добавляет фильтр в конец цепочки: он получает токены, уже прошедшие
+// встроенные преобразования и ранее добавленные фильтры, в порядке добавления.
+// Фильтры нужно добавлять до начала обработки.
+func (t *Tokenizer) AddFilter(filter TokenFilter) {
+	t.filters = append(t.filters, filter)
+}
+
+// Нормализация токена перед подсчетом: прогон через цепочку фильтров.
+// Возвращает false, если какой-либо фильтр отбросил токен; следующие фильтры не вызываются.
+func (t *Tokenizer) normalizeToken(token string) (string, bool) {
+	for _, filter := range t.filters {
+		var ok bool
+		if token, ok = filter(token); !ok {
+			return "", false
+		}
+	}
+	return token, true
+}
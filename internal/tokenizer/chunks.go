@@ -0,0 +1,112 @@
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/terratensor/vocab/internal/processor"
+)
+
+// Минимальный размер части файла при параллельной обработке
+const minFileChunkSize = 1 << 20
+
+// Проверка, можно ли обрабатывать файл частями: документная частота, позиции,
+// примеры, границы предложений и порядок первого появления токенов требуют
+// последовательного чтения всего файла
+func (t *Tokenizer) chunkable() bool {
+	return t.opts.WorkersPerFile > 1 && !t.opts.DocFreq && !t.opts.Index && t.opts.Examples == 0 &&
+		t.opts.EOSToken == "" && !t.opts.DecapSentenceStart && !t.opts.FirstSeen
+}
+
+// Границы частей большого текстового файла для параллельной обработки (WorkersPerFile).
+// Границы выравниваются по началам строк, поэтому ни строка, ни символ UTF-8 не разрезаются:
+// байт '\n' не встречается внутри многобайтовых последовательностей.
+// Возвращает nil, если файл нужно обрабатывать целиком.
+func (t *Tokenizer) fileChunks(file *os.File, proc processor.Processor) []int64 {
+	if !t.chunkable() {
+		return nil
+	}
+	// Сжатые и структурированные форматы читаются только последовательно
+	if _, ok := proc.(*processor.TextProcessor); !ok {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	size := info.Size()
+	chunks := int64(t.opts.WorkersPerFile)
+	if limit := size / minFileChunkSize; limit < chunks {
+		chunks = limit
+	}
+	if chunks < 2 {
+		return nil
+	}
+
+	bounds := []int64{0}
+	for i := int64(1); i < chunks; i++ {
+		offset, err := nextLineStart(file, size*i/chunks)
+		if err != nil {
+			return nil
+		}
+		if offset > bounds[len(bounds)-1] && offset < size {
+			bounds = append(bounds, offset)
+		}
+	}
+	return append(bounds, size)
+}
+
+// Смещение начала строки, следующей за байтом offset (или конца файла)
+func nextLineStart(file *os.File, offset int64) (int64, error) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.ReadAt(buf, offset)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return offset + int64(i) + 1, nil
+		}
+		offset += int64(n)
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Параллельная обработка частей файла с границами bounds и объединение их словарей.
+// Возвращает результат, число токенов с некорректным UTF-8 и первую ошибку чтения.
+func (t *Tokenizer) processChunks(ctx context.Context, file *os.File, filePath string, bounds []int64) (*fileResult, int, error) {
+	results := make([]*fileResult, len(bounds)-1)
+	invalid := make([]int, len(results))
+	errs := make([]error, len(results))
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = &fileResult{vocab: make(map[string]int)}
+			section := io.NewSectionReader(file, bounds[i], bounds[i+1]-bounds[i])
+			invalid[i], errs[i] = t.scanText(ctx, section, filePath, results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, 0, err
+	}
+	result, invalidTokens := results[0], invalid[0]
+	for i := 1; i < len(results); i++ {
+		for token, count := range results[i].vocab {
+			result.vocab[token] += count
+		}
+		invalidTokens += invalid[i]
+	}
+	return result, invalidTokens, nil
+}
@@ -0,0 +1,51 @@
+package tokenizer
+
+import (
+	"github.com/terratensor/vocab/internal/processor"
+)
+
+// Выбор процессора документа. При отдельном словаре кода строки кода, которые
+// процессор отделяет от текста, подсчитываются в result.code.
+func (t *Tokenizer) newProcessor(name string, result *fileResult) processor.Processor {
+	opts := t.opts.Processor
+	if opts.Code == processor.CodeSeparate {
+		code := make(map[string]int)
+		result.code = code
+		sentence := newSentenceState(false)
+		opts.CodeSink = func(line string) {
+			t.lineTokens(line, true, sentence, func(token string, start int) {
+				code[token]++
+			})
+		}
+	}
+	proc := processor.NewProcessor(name, opts)
+	result.wholeLines = processor.WholeLines(proc)
+	return proc
+}
+
+// Добавление токенов кода обработанного файла в словарь кода
+func (t *Tokenizer) recordCode(result *fileResult) {
+	t.codeMutex.Lock()
+	defer t.codeMutex.Unlock()
+	if t.codeVocab == nil {
+		t.codeVocab = make(map[string]int)
+	}
+	for token, count := range result.code {
+		t.codeVocab[token] += count
+	}
+}
+
+// CodeVocabulary возвращает словарь токенов кода, собранный из обработанных файлов
+// при Processor.Code == CodeSeparate
+func (t *Tokenizer) CodeVocabulary() map[string]int {
+	t.codeMutex.Lock()
+	defer t.codeMutex.Unlock()
+	vocab := make(map[string]int, len(t.codeVocab))
+	for token, count := range t.codeVocab {
+		vocab[token] = count
+	}
+	if t.opts.FoldCase {
+		vocab = foldCase(vocab)
+	}
+	return vocab
+}
@@ -0,0 +1,72 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Сглаживание частот при сравнении корпусов по умолчанию
+const DefaultCompareSmoothing = 0.5
+
+// SaveComparison сравнивает словари двух корпусов и сохраняет токены, упорядоченные
+// по убыванию логарифма отношения нормированных частот (строки "токен оценка частота_a частота_b").
+// Оценка равна log2(pA / pB), где pX = (частота + smoothing) / (N_X + smoothing * V),
+// N_X — число токенов корпуса, V — число разных токенов обоих корпусов. Сглаживание
+// позволяет сравнивать токены, встречающиеся только в одном корпусе. В начале файла —
+// токены, характерные для первого корпуса, в конце — для второго.
+// Токены с суммарной частотой ниже MinCount пропускаются.
+func (t *Tokenizer) SaveComparison(a, b map[string]int, smoothing float64, outputFile string) error {
+	fmt.Fprintln(t.out, "Comparing vocabularies...")
+	type TokenRatio struct {
+		Token  string
+		Ratio  float64
+		CountA int
+		CountB int
+	}
+
+	tokens := make(map[string]struct{}, len(a)+len(b))
+	for token := range a {
+		tokens[token] = struct{}{}
+	}
+	for token := range b {
+		tokens[token] = struct{}{}
+	}
+	types := float64(len(tokens))
+	totalA := float64(TotalCount(a)) + smoothing*types
+	totalB := float64(TotalCount(b)) + smoothing*types
+
+	ratios := make([]TokenRatio, 0, len(tokens))
+	for token := range tokens {
+		countA, countB := a[token], b[token]
+		if countA+countB < t.opts.MinCount {
+			continue
+		}
+		pA := (float64(countA) + smoothing) / totalA
+		pB := (float64(countB) + smoothing) / totalB
+		ratios = append(ratios, TokenRatio{Token: token, Ratio: math.Log2(pA / pB), CountA: countA, CountB: countB})
+	}
+	sort.Slice(ratios, func(i, j int) bool {
+		if ratios[i].Ratio != ratios[j].Ratio {
+			return ratios[i].Ratio > ratios[j].Ratio
+		}
+		return ratios[i].Token < ratios[j].Token
+	})
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, tr := range ratios {
+		fmt.Fprintf(file, "%s %.4f %d %d\n", tr.Token, tr.Ratio, tr.CountA, tr.CountB)
+	}
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	fmt.Fprintf(t.out, "Compared %d tokens\n", len(ratios))
+	return nil
+}
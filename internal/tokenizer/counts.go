@@ -0,0 +1,66 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// countValue — тип частоты токена: целые частоты по умолчанию,
+// дробные — для взвешенных и нормированных словарей
+type countValue interface {
+	int | float64
+}
+
+// Точность дробных частот по умолчанию (знаков после запятой)
+const defaultCountPrecision = 6
+
+// Запись частоты: целые — как есть, дробные — с точностью CountPrecision
+func countText[C countValue](t *Tokenizer, count C) string {
+	switch c := any(count).(type) {
+	case int:
+		return strconv.Itoa(c)
+	case float64:
+		precision := t.opts.CountPrecision
+		if precision <= 0 {
+			precision = defaultCountPrecision
+		}
+		return strconv.FormatFloat(c, 'f', precision, 64)
+	}
+	panic("unreachable")
+}
+
+// Разбор дробной частоты; бесконечности и NaN не допускаются
+func parseFloatCount(text string) (float64, error) {
+	count, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsInf(count, 0) || math.IsNaN(count) {
+		return 0, fmt.Errorf("invalid count %q", text)
+	}
+	return count, nil
+}
+
+// PerMillion пересчитывает частоты словаря в число вхождений на миллион токенов:
+// count * 1e6 / total, где total — общее число токенов корпуса. Такие частоты
+// сопоставимы между корпусами разного размера.
+func PerMillion(vocab map[string]int, total int) map[string]float64 {
+	scaled := make(map[string]float64, len(vocab))
+	if total <= 0 {
+		return scaled
+	}
+	for token, count := range vocab {
+		scaled[token] = float64(count) * 1e6 / float64(total)
+	}
+	return scaled
+}
+
+// TotalCount возвращает общее число вхождений токенов словаря
+func TotalCount(vocab map[string]int) int {
+	total := 0
+	for _, count := range vocab {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,36 @@
+package tokenizer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Проверка, встречался ли уже файл с таким же содержимым.
+// Содержимое хешируется потоково; возвращается путь первого файла с тем же содержимым.
+func (t *Tokenizer) duplicateOf(filePath string) (string, bool) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", false // Ошибку открытия сообщит обработка файла
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", false
+	}
+	key := fmt.Sprintf("%d:%x", size, hash.Sum(nil))
+
+	t.contentMutex.Lock()
+	defer t.contentMutex.Unlock()
+	if t.contents == nil {
+		t.contents = make(map[string]string)
+	}
+	if first, ok := t.contents[key]; ok {
+		return first, true
+	}
+	t.contents[key] = filePath
+	return "", false
+}
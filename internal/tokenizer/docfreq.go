@@ -0,0 +1,82 @@
+package tokenizer
+
+import "strings"
+
+// Значение DocDelimiter, при котором документы разделяются пустыми строками
+const BlankLineDelimiter = "blank"
+
+// Проверка, является ли строка разделителем документов
+func (t *Tokenizer) isDocDelimiter(line string) bool {
+	switch t.opts.DocDelimiter {
+	case "":
+		return false
+	case BlankLineDelimiter:
+		return strings.TrimSpace(line) == ""
+	}
+	return strings.TrimSpace(line) == t.opts.DocDelimiter
+}
+
+// Подсчет документной частоты в пределах одного файла:
+// каждый токен учитывается не больше одного раза на документ
+type docCounter struct {
+	t      *Tokenizer
+	result *fileResult
+	tokens map[string]struct{} // Токены текущего документа
+}
+
+func (t *Tokenizer) newDocCounter(result *fileResult) *docCounter {
+	if !t.opts.DocFreq {
+		return &docCounter{}
+	}
+	result.docFreq = make(map[string]int)
+	return &docCounter{t: t, result: result, tokens: make(map[string]struct{})}
+}
+
+// Учет токена в текущем документе
+func (d *docCounter) add(token string) {
+	if d.tokens != nil {
+		d.tokens[d.t.foldKey(token)] = struct{}{}
+	}
+}
+
+// Завершение текущего документа; пустые документы не учитываются
+func (d *docCounter) end() {
+	if len(d.tokens) == 0 {
+		return
+	}
+	d.result.documents++
+	for token := range d.tokens {
+		d.result.docFreq[token]++
+	}
+	clear(d.tokens)
+}
+
+// Добавление документных частот файла к общим
+func (t *Tokenizer) recordDocFreq(result *fileResult) {
+	t.docMutex.Lock()
+	defer t.docMutex.Unlock()
+	if t.docFreq == nil {
+		t.docFreq = make(map[string]int)
+	}
+	for token, count := range result.docFreq {
+		t.docFreq[token] += count
+	}
+	t.documents += result.documents
+}
+
+// DocumentFrequencies возвращает число документов, в которых встретился каждый токен словаря.
+// Документная частота считается при обработке директории с включенным DocFreq.
+func (t *Tokenizer) DocumentFrequencies(vocab map[string]int) map[string]int {
+	docFreq := make(map[string]int, len(vocab))
+	for token := range vocab {
+		if count, ok := t.docFreq[t.foldKey(token)]; ok {
+			docFreq[token] = count
+		}
+	}
+	return docFreq
+}
+
+// Documents возвращает число документов, обработанных с включенным DocFreq
+func (t *Tokenizer) Documents() int {
+	return t.documents
+}
@@ -0,0 +1,53 @@
+package tokenizer
+
+import "testing"
+
+func newTestTokenizer(t *testing.T, opts Options) *Tokenizer {
+	t.Helper()
+	opts.ErrorMode = ErrorModeNone
+	opts.Quiet = true
+	tok, err := NewTokenizer(opts)
+	if err != nil {
+		t.Fatalf("NewTokenizer: %v", err)
+	}
+	t.Cleanup(tok.Close)
+	return tok
+}
+
+func TestDOCXTableWords(t *testing.T) {
+	tok := newTestTokenizer(t, Options{})
+	vocab, err := tok.BuildFileVocabulary("testdata/table.docx")
+	if err != nil {
+		t.Fatalf("BuildFileVocabulary: %v", err)
+	}
+	// Абзац тела, ячейки таблицы (каждая — абзац из одного слова) и колонтитул
+	for _, word := range []string{"Квартальный", "отчет", "Выручка", "Расходы", "Итого", "сальдо", "Колонтитул"} {
+		if vocab[word] != 1 {
+			t.Errorf("vocab[%q] = %d, want 1 (vocabulary: %v)", word, vocab[word], vocab)
+		}
+	}
+}
+
+// Строка из одного слова учитывается целиком, позиции считаются без пробелов вокруг
+func TestTokenizeLineWholeLine(t *testing.T) {
+	tok := newTestTokenizer(t, Options{})
+	segments := tok.tokenizeLine("  Итого ", true)
+	if len(segments) != 1 || segments[0].Text != "Итого" || segments[0].Start != 2 || segments[0].End != 7 {
+		t.Errorf("tokenizeLine(%q, true) = %v, want [Итого 2 7]", "  Итого ", segments)
+	}
+}
+
+// Строки из одного слова учитываются для всех процессоров, выводящих текст по строкам
+func TestNewProcessorWholeLines(t *testing.T) {
+	opts := Options{}
+	opts.Processor.CSVColumn = "text"
+	opts.Processor.JSONField = ".text"
+	tok := newTestTokenizer(t, opts)
+	for _, name := range []string{"report.docx", "report.docx.gz", "data.csv", "data.tsv", "data.jsonl", "data.ndjson.gz", "notes.md", "notes.markdown", "book.pdf"} {
+		result := &fileResult{}
+		tok.newProcessor(name, result)
+		if !result.wholeLines {
+			t.Errorf("newProcessor(%q): single-word lines are not counted", name)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package tokenizer
+
+import "fmt"
+
+// Политики обработки повторяющихся токенов при загрузке словаря
+const (
+	DuplicatesSum   = "sum"   // Суммировать частоты
+	DuplicatesLast  = "last"  // Оставлять частоту из последней строки
+	DuplicatesFirst = "first" // Оставлять частоту из первой строки
+	DuplicatesError = "error" // Прерывать загрузку с ошибкой
+)
+
+// Проверка политики обработки повторяющихся токенов
+func validDuplicatePolicy(policy string) bool {
+	switch policy {
+	case "", DuplicatesSum, DuplicatesLast, DuplicatesFirst, DuplicatesError:
+		return true
+	}
+	return false
+}
+
+// Добавление записи в загружаемый словарь согласно политике Duplicates
+func addLoadedEntry[C countValue](t *Tokenizer, vocab map[string]C, token string, count C) error {
+	previous, seen := vocab[token]
+	if !seen {
+		vocab[token] = count
+		return nil
+	}
+
+	switch t.opts.Duplicates {
+	case DuplicatesLast:
+		vocab[token] = count
+	case DuplicatesFirst:
+	case DuplicatesError:
+		return fmt.Errorf("duplicate token %q (counts %v and %v)", token, previous, count)
+	default:
+		vocab[token] += count
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package tokenizer
+
+import (
+	"unicode"
+
+	seg "github.com/terratensor/segment/segment"
+)
+
+// Диапазоны символов, которые сами по себе отображаются как эмодзи
+var emojiBase = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x231A, Hi: 0x231B, Stride: 1}, // ⌚ ⌛
+		{Lo: 0x23E9, Hi: 0x23F3, Stride: 1}, // ⏩ … ⏳
+		{Lo: 0x23F8, Hi: 0x23FA, Stride: 1}, // ⏸ ⏹ ⏺
+		{Lo: 0x2600, Hi: 0x27BF, Stride: 1}, // Разные символы и дингбаты: ☀ ☕ ❤ ✅
+		{Lo: 0x2B05, Hi: 0x2B07, Stride: 1}, // ⬅ ⬆ ⬇
+		{Lo: 0x2B1B, Hi: 0x2B1C, Stride: 1}, // ⬛ ⬜
+		{Lo: 0x2B50, Hi: 0x2B50, Stride: 1}, // ⭐
+		{Lo: 0x2B55, Hi: 0x2B55, Stride: 1}, // ⭕
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1F000, Hi: 0x1FAFF, Stride: 1}, // Пиктограммы, смайлики, транспорт, флаги
+	},
+}
+
+const (
+	zeroWidthJoiner   = '\u200D'
+	emojiPresentation = '\uFE0F' // Селектор варианта: отображать символ как эмодзи
+	keycapMark        = '\u20E3' // Комбинируемая рамка клавиши: 1️⃣
+)
+
+// Проверка, является ли руна модификатором предыдущего эмодзи: селектор варианта,
+// оттенок кожи, рамка клавиши или тег флага субрегиона
+func isEmojiModifier(r rune) bool {
+	return r == emojiPresentation || r == '\uFE0E' || r == keycapMark ||
+		r >= 0x1F3FB && r <= 0x1F3FF || r >= 0xE0020 && r <= 0xE007F
+}
+
+// Проверка, является ли руна региональным индикатором (пара индикаторов образует флаг)
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Длина в рунах последовательности эмодзи, начинающейся с runes[i] (0 — не эмодзи).
+// Последовательность — это эмодзи с модификаторами (оттенком кожи, селектором варианта),
+// цепочка таких эмодзи, соединенных ZWJ (👨‍👩‍👧), флаг из двух региональных индикаторов (🇷🇺)
+// или клавиша (1️⃣).
+func emojiLength(runes []rune, i int) int {
+	start := i
+	if isRegionalIndicator(runes[i]) && i+1 < len(runes) && isRegionalIndicator(runes[i+1]) {
+		return 2
+	}
+	for {
+		if !isEmojiStart(runes, i) {
+			// ZWJ без следующего эмодзи в последовательность не входит
+			if i > start {
+				i--
+			}
+			return i - start
+		}
+		i++
+		for i < len(runes) && isEmojiModifier(runes[i]) {
+			i++
+		}
+		if i+1 >= len(runes) || runes[i] != zeroWidthJoiner {
+			return i - start
+		}
+		i++
+	}
+}
+
+// Проверка, начинается ли с runes[i] эмодзи: символ эмодзи, символ с селектором
+// варианта эмодзи (©️, ‼️) или клавиша (цифра, # или * с рамкой)
+func isEmojiStart(runes []rune, i int) bool {
+	if i >= len(runes) {
+		return false
+	}
+	r := runes[i]
+	if unicode.Is(emojiBase, r) && !isEmojiModifier(r) {
+		return true
+	}
+	if i+1 < len(runes) && runes[i+1] == emojiPresentation {
+		return true
+	}
+	return (r >= '0' && r <= '9' || r == '#' || r == '*') && i+1 < len(runes) && runes[i+1] == keycapMark
+}
+
+// Проверка, состоит ли токен из одной последовательности эмодзи
+func isEmojiToken(token string) bool {
+	runes := []rune(token)
+	return len(runes) > 0 && emojiLength(runes, 0) == len(runes)
+}
+
+// Склейка соседних сегментов, между которыми segment разорвал последовательность эмодзи:
+// модификатор отделен от символа ("1" + "️⃣") или часть следует за ZWJ
+func joinEmojiParts(segments []seg.Segment) []seg.Segment {
+	joined := make([]seg.Segment, 0, len(segments))
+	for _, s := range segments {
+		if n := len(joined); n > 0 && joined[n-1].End == s.Start {
+			last := []rune(joined[n-1].Text)
+			first := []rune(s.Text)
+			if len(first) > 0 && isEmojiModifier(first[0]) || len(last) > 0 && last[len(last)-1] == zeroWidthJoiner {
+				joined[n-1].Text += s.Text
+				joined[n-1].End = s.End
+				continue
+			}
+		}
+		joined = append(joined, s)
+	}
+	return joined
+}
+
+// Выделение последовательностей эмодзи в отдельные токены ("a👍🏽b" -> "a", "👍🏽", "b")
+// с сохранением позиций частей
+func splitEmoji(token seg.Segment) []seg.Segment {
+	runes := []rune(token.Text)
+	var parts []seg.Segment
+	add := func(from, to int) {
+		if from < to {
+			parts = append(parts, seg.Segment{
+				Text:  string(runes[from:to]),
+				Start: token.Start + from,
+				End:   token.Start + to,
+			})
+		}
+	}
+
+	textStart := 0
+	for i := 0; i < len(runes); {
+		n := emojiLength(runes, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		add(textStart, i)
+		add(i, i+n)
+		i += n
+		textStart = i
+	}
+	if parts == nil {
+		return []seg.Segment{token}
+	}
+	add(textStart, len(runes))
+	return parts
+}
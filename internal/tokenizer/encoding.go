@@ -0,0 +1,109 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Кодировки выходного словаря
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingWin1251 = "windows-1251"
+	EncodingLatin1  = "iso-8859-1"
+)
+
+// Обработка символов, которых нет в выходной кодировке
+const (
+	UnmappableReplace = "replace" // Заменять символ на '?'
+	UnmappableSkip    = "skip"    // Пропускать токен целиком
+	UnmappableError   = "error"   // Прерывать сохранение с ошибкой
+)
+
+// Символы windows-1251 с кодами 0x80–0xBF; 0xC0–0xFF — буквы А–я (U+0410–U+044F).
+// Код 0x98 не используется.
+var win1251High = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', utf8.RuneError, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	' ', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '­', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// Однобайтовая кодировка: отображение символа в байт
+type charset struct {
+	name  string
+	bytes map[rune]byte // Символы с кодами 0x80–0xFF (коды 0x00–0x7F совпадают с ASCII)
+}
+
+// Поиск кодировки по имени; nil — UTF-8, запись без перекодирования
+func lookupCharset(name string) (*charset, error) {
+	switch strings.ToLower(name) {
+	case "", EncodingUTF8, "utf8":
+		return nil, nil
+	case EncodingWin1251, "cp1251":
+		cs := &charset{name: EncodingWin1251, bytes: make(map[rune]byte, 128)}
+		for i, r := range win1251High {
+			if r != utf8.RuneError {
+				cs.bytes[r] = byte(0x80 + i)
+			}
+		}
+		for i := 0; i < 64; i++ {
+			cs.bytes['А'+rune(i)] = byte(0xC0 + i)
+		}
+		return cs, nil
+	case EncodingLatin1, "latin1":
+		cs := &charset{name: EncodingLatin1, bytes: make(map[rune]byte, 128)}
+		for i := 0x80; i <= 0xFF; i++ {
+			cs.bytes[rune(i)] = byte(i)
+		}
+		return cs, nil
+	}
+	return nil, fmt.Errorf("unknown output encoding %q (supported: %s, %s, %s)", name, EncodingUTF8, EncodingWin1251, EncodingLatin1)
+}
+
+// Проверка режима обработки непредставимых символов
+func validUnmappableMode(mode string) bool {
+	switch mode {
+	case "", UnmappableReplace, UnmappableSkip, UnmappableError:
+		return true
+	}
+	return false
+}
+
+// Перекодирование строки выходного словаря. Возвращает false, если строку нужно
+// пропустить (режим skip), и ошибку в режиме error.
+func (cs *charset) encode(s, mode string) (string, bool, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteByte(byte(r))
+			continue
+		}
+		if c, ok := cs.bytes[r]; ok {
+			b.WriteByte(c)
+			continue
+		}
+		switch mode {
+		case UnmappableSkip:
+			return "", false, nil
+		case UnmappableError:
+			return "", false, fmt.Errorf("character %q (%U) in %q cannot be encoded in %s", r, r, strings.TrimSuffix(s, "\n"), cs.name)
+		}
+		b.WriteByte('?')
+	}
+	return b.String(), true, nil
+}
+
+// Запись строки словаря в выходной кодировке (OutputEncoding)
+func (t *Tokenizer) writeEntry(file *outputWriter, entry string) error {
+	if t.charset != nil {
+		encoded, ok, err := t.charset.encode(entry, t.opts.Unmappable)
+		if err != nil || !ok {
+			return err
+		}
+		entry = encoded
+	}
+	_, err := file.WriteString(entry)
+	return err
+}
@@ -0,0 +1,118 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Папка ошибок по умолчанию
+const DefaultErrorDir = "vocab_errors"
+
+// Режимы обработки файлов, которые не удалось обработать
+const (
+	ErrorModeCopy = "copy" // Записывать ошибку в лог и копировать файл в папку ошибок
+	ErrorModeList = "list" // Только перечислять пути файлов в логе, без копирования
+	ErrorModeNone = "none" // Не вести лог и не создавать папку ошибок
+)
+
+// Проверка режима обработки ошибок
+func validErrorMode(mode string) bool {
+	switch mode {
+	case "", ErrorModeCopy, ErrorModeList, ErrorModeNone:
+		return true
+	}
+	return false
+}
+
+// Создание папки ошибок и открытие лог-файла (по умолчанию — в папке ошибок)
+func openErrorLog(errorDir, logPath string) (*os.File, error) {
+	if err := os.MkdirAll(errorDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create error directory: %v", err)
+	}
+	if logPath == "" {
+		logPath = filepath.Join(errorDir, "vocab_errors.log")
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %v", err)
+	}
+	return logFile, nil
+}
+
+// Подсказка о месте лога ошибок для предупреждений: " (see vocab_errors/vocab_errors.log)"
+func (t *Tokenizer) logHint() string {
+	if t.logFile == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (see %s)", t.logFile.Name())
+}
+
+// Учет файла, который не удалось обработать: файл запоминается для итоговой сводки
+// и обрабатывается согласно ErrorMode. В режиме list в лог пишется строка
+// "FAILED\t<путь в кавычках Go>\t<сообщение>", которую легко разобрать для повторной обработки.
+func (t *Tokenizer) fileFailed(filePath, kind, message string) {
+	t.failuresMutex.Lock()
+	t.failures = append(t.failures, FileFailure{Path: filePath, Kind: kind, Message: message})
+	t.failuresMutex.Unlock()
+	t.metrics.fileFailed(kind)
+
+	switch t.opts.ErrorMode {
+	case ErrorModeNone:
+	case ErrorModeList:
+		t.logError(fmt.Sprintf("FAILED\t%q\t%s", filePath, message))
+	default:
+		t.logError(message)
+		t.copyErrorFile(filePath)
+	}
+}
+
+// Виды ошибок обработки файлов
+const (
+	FailureOpen    = "open"    // Файл не удалось открыть
+	FailureFormat  = "format"  // Процессор не смог извлечь текст (поврежденный или неподдерживаемый файл)
+	FailureRead    = "read"    // Ошибка чтения текста файла
+	FailureTimeout = "timeout" // Обработка превысила FileTimeout
+)
+
+// FileFailure описывает файл, который не удалось обработать
+type FileFailure struct {
+	Path    string // Путь к файлу
+	Kind    string // Вид ошибки: FailureOpen, FailureFormat, FailureRead или FailureTimeout
+	Message string // Сообщение об ошибке, записанное в лог
+}
+
+// Failures возвращает файлы, которые не удалось обработать, в порядке возникновения ошибок
+func (t *Tokenizer) Failures() []FileFailure {
+	t.failuresMutex.Lock()
+	defer t.failuresMutex.Unlock()
+	return append([]FileFailure(nil), t.failures...)
+}
+
+// Итог обработки с ошибками в Diagnostics: число файлов с ошибками и виды ошибок
+// (по убыванию числа файлов) с примером сообщения для каждого вида
+func (t *Tokenizer) printFailureSummary(totalFiles int) {
+	failures := t.Failures()
+	if len(failures) == 0 {
+		return
+	}
+
+	byKind := make(map[string][]FileFailure)
+	var kinds []string
+	for _, failure := range failures {
+		if _, ok := byKind[failure.Kind]; !ok {
+			kinds = append(kinds, failure.Kind)
+		}
+		byKind[failure.Kind] = append(byKind[failure.Kind], failure)
+	}
+	sort.SliceStable(kinds, func(i, j int) bool {
+		return len(byKind[kinds[i]]) > len(byKind[kinds[j]])
+	})
+
+	fmt.Fprintf(t.diag, "Failed to process %d of %d files%s:\n", len(failures), totalFiles, t.logHint())
+	for _, kind := range kinds {
+		group := byKind[kind]
+		fmt.Fprintf(t.diag, "  %s: %d files, e.g. %s\n", kind, len(group), group[0].Message)
+	}
+}
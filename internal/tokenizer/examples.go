@@ -0,0 +1,137 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Максимальная длина сохраняемого примера в рунах
+const maxExampleLength = 200
+
+// Пример строки с токеном и ее случайный ключ
+type example struct {
+	key  uint64
+	text string
+}
+
+// Выборка примеров токена: K строк с наименьшими ключами.
+// Ключи — хеш от Seed, файла, номера строки и токена, поэтому выборка равномерна среди строк
+// с токеном, воспроизводима и не зависит от порядка обработки файлов.
+type exampleReservoir struct {
+	examples []example // Упорядочены по возрастанию ключа
+}
+
+// Добавление примера с сохранением не больше k примеров
+func (r *exampleReservoir) add(e example, k int) {
+	if len(r.examples) == k && e.key >= r.examples[k-1].key {
+		return
+	}
+	i := sort.Search(len(r.examples), func(i int) bool { return r.examples[i].key >= e.key })
+	if i < len(r.examples) && r.examples[i].key == e.key {
+		return // Та же строка уже выбрана
+	}
+	if len(r.examples) < k {
+		r.examples = append(r.examples, example{})
+	}
+	copy(r.examples[i+1:], r.examples[i:])
+	r.examples[i] = e
+}
+
+// Случайный ключ строки для токена
+func (t *Tokenizer) exampleKey(filePath string, lineNumber int, token string) uint64 {
+	h := fnv.New64a()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(t.opts.Seed))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(lineNumber))
+	h.Write(buf[:])
+	h.Write([]byte(filePath))
+	h.Write([]byte{0})
+	h.Write([]byte(token))
+	return h.Sum64()
+}
+
+// Учет строки как возможного примера токена в результате обработки файла
+func (t *Tokenizer) addExample(result *fileResult, token, filePath string, lineNumber int, line string) {
+	if result.examples == nil {
+		result.examples = make(map[string]*exampleReservoir)
+	}
+	key := t.foldKey(token)
+	r, ok := result.examples[key]
+	if !ok {
+		r = &exampleReservoir{}
+		result.examples[key] = r
+	}
+
+	e := example{key: t.exampleKey(filePath, lineNumber, key)}
+	if len(r.examples) == t.opts.Examples && e.key >= r.examples[len(r.examples)-1].key {
+		return // Строка не попадет в выборку, текст не нужен
+	}
+	e.text = exampleText(line)
+	r.add(e, t.opts.Examples)
+}
+
+// Текст примера: пробельные символы заменяются пробелами, длина ограничивается
+func exampleText(line string) string {
+	text := strings.Join(strings.Fields(line), " ")
+	if runes := []rune(text); len(runes) > maxExampleLength {
+		text = string(runes[:maxExampleLength]) + "…"
+	}
+	return text
+}
+
+// Добавление примеров файла к общей выборке
+func (t *Tokenizer) recordExamples(result *fileResult) {
+	t.examplesMutex.Lock()
+	defer t.examplesMutex.Unlock()
+	if t.examples == nil {
+		t.examples = make(map[string]*exampleReservoir)
+	}
+	for key, local := range result.examples {
+		r, ok := t.examples[key]
+		if !ok {
+			t.examples[key] = local
+			continue
+		}
+		for _, e := range local.examples {
+			r.add(e, t.opts.Examples)
+		}
+	}
+}
+
+// SaveExamples сохраняет примеры строк для токенов словаря: токен и до Examples строк
+// через табуляцию.
+func (t *Tokenizer) SaveExamples(vocab map[string]int, outputFile string) error {
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, token := range tokens {
+		r, ok := t.examples[t.foldKey(token)]
+		if !ok {
+			continue
+		}
+		file.WriteString(token)
+		for _, e := range r.examples {
+			file.WriteString("\t" + e.text)
+		}
+		file.WriteString("\n")
+	}
+
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
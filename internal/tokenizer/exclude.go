@@ -0,0 +1,42 @@
+package tokenizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Шаблон ExcludeGlobs, которому соответствует файл dirPath/name (false, если таких нет).
+// Шаблон сравнивается (filepath.Match) с путем файла и с каждой его конечной частью,
+// начинающейся после разделителя, в том числе с именем файла: "*_draft.txt" исключает
+// файлы по имени, а "archive/*" — файлы любой директории archive.
+func (t *Tokenizer) excludedBy(dirPath, name string) (string, bool) {
+	if len(t.opts.ExcludeGlobs) == 0 {
+		return "", false
+	}
+	path := filepath.ToSlash(filepath.Join(dirPath, name))
+	for _, pattern := range t.opts.ExcludeGlobs {
+		pattern = filepath.ToSlash(pattern)
+		for suffix := path; ; {
+			if ok, _ := filepath.Match(pattern, suffix); ok {
+				return pattern, true
+			}
+			i := strings.IndexByte(suffix, '/')
+			if i < 0 {
+				break
+			}
+			suffix = suffix[i+1:]
+		}
+	}
+	return "", false
+}
+
+// Проверка шаблонов ExcludeGlobs
+func validExcludeGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
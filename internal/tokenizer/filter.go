@@ -0,0 +1,65 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FilterVocabulary применяет к собранному словарю фильтры, которым нужен словарь целиком
+// или статистика, собранная при обработке файлов: минимальную документную частоту и
+// минимальную частоту. Редкие токены (ниже MinCount или порога MinPercentile) отбрасываются
+// или, если задан UnkToken, суммируются в одну запись UnkToken, так что общее число вхождений
+// сохраняется.
+func (t *Tokenizer) FilterVocabulary(vocab map[string]int) map[string]int {
+	defer t.timeStage(timingFilter)()
+	minCount := t.opts.MinCount
+	if t.opts.MinPercentile > 0 {
+		threshold := percentileCount(vocab, t.opts.MinPercentile)
+		fmt.Fprintf(t.out, "Frequency at percentile %g: %d\n", t.opts.MinPercentile, threshold)
+		if threshold > minCount {
+			minCount = threshold
+		}
+	}
+	if t.opts.MinDocFreq <= 1 && minCount <= 1 {
+		return vocab
+	}
+
+	filtered := make(map[string]int, len(vocab))
+	unknown := 0
+	for token, count := range vocab {
+		if t.opts.MinDocFreq > 1 && t.docFreq[t.foldKey(token)] < t.opts.MinDocFreq {
+			continue
+		}
+		if count < minCount {
+			unknown += count
+			continue
+		}
+		filtered[token] += count
+	}
+	if t.opts.UnkToken != "" && unknown > 0 {
+		filtered[t.opts.UnkToken] += unknown
+	}
+
+	fmt.Fprintf(t.out, "Filtered vocabulary: %d/%d tokens kept\n", len(filtered), len(vocab))
+	return filtered
+}
+
+// Частота на заданном процентиле (0–100) упорядоченных по возрастанию частот токенов:
+// частота токена с индексом floor(percentile/100*n). Токены с этой частотой остаются
+// в словаре, поэтому при равных частотах на границе отбрасывается меньше percentile% токенов.
+func percentileCount(vocab map[string]int, percentile float64) int {
+	if len(vocab) == 0 {
+		return 0
+	}
+	counts := make([]int, 0, len(vocab))
+	for _, count := range vocab {
+		counts = append(counts, count)
+	}
+	sort.Ints(counts)
+
+	index := int(percentile / 100 * float64(len(counts)))
+	if index >= len(counts) {
+		index = len(counts) - 1
+	}
+	return counts[index]
+}
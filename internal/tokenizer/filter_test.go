@@ -0,0 +1,26 @@
+package tokenizer
+
+import (
+	"maps"
+	"testing"
+)
+
+// С UnkToken редкие токены суммируются в одну запись, и общее число вхождений не меняется
+func TestFilterVocabularyUnkTokenKeepsTotal(t *testing.T) {
+	vocab := map[string]int{"кот": 10, "пес": 5, "мышь": 2, "еж": 1, "уж": 1}
+	tok := newTestTokenizer(t, Options{MinCount: 3, UnkToken: "<unk>"})
+	filtered := tok.FilterVocabulary(maps.Clone(vocab))
+	want := map[string]int{"кот": 10, "пес": 5, "<unk>": 4}
+	if !maps.Equal(filtered, want) {
+		t.Errorf("FilterVocabulary = %v, want %v", filtered, want)
+	}
+	if got, total := TotalCount(filtered), TotalCount(vocab); got != total {
+		t.Errorf("total count = %d after filtering, want %d", got, total)
+	}
+
+	// Без UnkToken редкие токены отбрасываются вместе с их вхождениями
+	tok = newTestTokenizer(t, Options{MinCount: 3})
+	if got := TotalCount(tok.FilterVocabulary(maps.Clone(vocab))); got != 15 {
+		t.Errorf("total count = %d after filtering without UnkToken, want 15", got)
+	}
+}
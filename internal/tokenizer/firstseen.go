@@ -0,0 +1,28 @@
+package tokenizer
+
+// Сортировка словаря в порядке первого появления токенов в тексте (требует FirstSeen)
+const SortFirstSeen = "firstseen"
+
+// Запоминание порядкового номера токена, если он встретился в файле впервые
+func recordFirstSeen(result *fileResult, token string) {
+	if result.order == nil {
+		result.order = make(map[string]int)
+	}
+	if _, ok := result.order[token]; !ok {
+		result.order[token] = len(result.order)
+	}
+}
+
+// Сравнение токенов по первому появлению. Токены, которых не было в тексте
+// (например, -unk-token), идут в конце по алфавиту.
+func (t *Tokenizer) firstSeenLess(a, b string) bool {
+	posA, okA := t.firstSeen[a]
+	posB, okB := t.firstSeen[b]
+	if okA != okB {
+		return okA
+	}
+	if okA {
+		return posA < posB
+	}
+	return a < b
+}
@@ -0,0 +1,113 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Форматы вывода словаря
+const (
+	FormatText    = "text"    // Строки "токен частота", пригодные для повторной загрузки
+	FormatAligned = "aligned" // Выровненные столбцы для чтения человеком, не для повторной загрузки
+	FormatTokens  = "tokens"  // Только токены, по одному в строке, без частот
+
+	FormatSentencePiece = "sentencepiece" // Словарь SentencePiece: "▁токен\tлогарифм вероятности"
+)
+
+// Проверка формата вывода
+func validFormat(format string) bool {
+	switch format {
+	case "", FormatText, FormatAligned, FormatTokens, FormatSentencePiece:
+		return true
+	}
+	return false
+}
+
+// Построение функции форматирования строки словаря
+func entryFormatter[C countValue](t *Tokenizer, vocab map[string]C) func(token string, count C) string {
+	if t.opts.Format == FormatSentencePiece {
+		return sentencePieceFormatter(vocab)
+	}
+	if t.opts.Format == FormatTokens {
+		return func(token string, count C) string {
+			return token + "\n"
+		}
+	}
+	if t.opts.Format != FormatAligned {
+		return func(token string, count C) string {
+			return token + " " + countText(t, count) + "\n"
+		}
+	}
+
+	// Ширина столбцов по самому длинному токену и самой длинной частоте
+	tokenWidth, countWidth := 0, 0
+	for token, count := range vocab {
+		if n := utf8.RuneCountInString(token); n > tokenWidth {
+			tokenWidth = n
+		}
+		if n := len(formatCount(t, count)); n > countWidth {
+			countWidth = n
+		}
+	}
+
+	return func(token string, count C) string {
+		padding := strings.Repeat(" ", tokenWidth-utf8.RuneCountInString(token))
+		return fmt.Sprintf("%s%s  %*s\n", token, padding, countWidth, formatCount(t, count))
+	}
+}
+
+// Форматирование частоты для формата aligned, при необходимости с разделителями тысяч
+// или сокращенно (HumanCounts)
+func formatCount[C countValue](t *Tokenizer, count C) string {
+	if t.opts.HumanCounts {
+		if text, ok := humanCount(float64(count)); ok {
+			return text
+		}
+	}
+	text := countText(t, count)
+	if !t.opts.ThousandsSep {
+		return text
+	}
+
+	sign, fraction := "", ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		text, fraction = text[:i], text[i:]
+	}
+	var b strings.Builder
+	for i, digit := range text {
+		if i > 0 && (len(text)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
+
+// Суффиксы сокращенной записи частот: тысячи, миллионы, миллиарды, триллионы
+var humanSuffixes = []string{"K", "M", "B", "T"}
+
+// Сокращенная запись частоты с одним знаком после запятой: 1.2M, 3.4B.
+// Возвращает false для частот меньше 1000, которые записываются как есть.
+func humanCount(count float64) (string, bool) {
+	value := math.Abs(count)
+	if value < 1000 {
+		return "", false
+	}
+	// Следующий суффикс выбирается и тогда, когда округление дает 1000.0 (999 950 -> 1.0M)
+	scaled, suffix := value/1000, 0
+	for suffix < len(humanSuffixes)-1 && math.Round(scaled*10)/10 >= 1000 {
+		scaled /= 1000
+		suffix++
+	}
+	sign := ""
+	if count < 0 {
+		sign = "-"
+	}
+	return sign + strconv.FormatFloat(scaled, 'f', 1, 64) + humanSuffixes[suffix], true
+}
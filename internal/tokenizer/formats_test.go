@@ -0,0 +1,97 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/terratensor/vocab/internal/processor"
+)
+
+// Словарь файла name с содержимым content
+func buildTestFile(t *testing.T, opts Options, name, content string) map[string]int {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vocab, err := newTestTokenizer(t, opts).BuildFileVocabulary(path)
+	if err != nil {
+		t.Fatalf("BuildFileVocabulary: %v", err)
+	}
+	return vocab
+}
+
+func checkCounts(t *testing.T, vocab map[string]int, want map[string]int) {
+	t.Helper()
+	for token, count := range want {
+		if vocab[token] != count {
+			t.Errorf("vocab[%q] = %d, want %d (vocabulary: %v)", token, vocab[token], count, vocab)
+		}
+	}
+}
+
+// Ячейки из одного слова и строки многострочных полей учитываются
+func TestCSVSingleWordCells(t *testing.T) {
+	opts := Options{}
+	opts.Processor.CSVColumn = "text"
+	vocab := buildTestFile(t, opts, "data.csv", "id,text\n1,Hello\n2,Hello world\n3,\"multi\nline\"\n")
+	checkCounts(t, vocab, map[string]int{"Hello": 2, "world": 1, "multi": 1, "line": 1, "1": 0, "id": 0})
+
+	vocab = buildTestFile(t, opts, "data.tsv", "id\ttext\n1\tHello\n2\tHello world\n")
+	checkCounts(t, vocab, map[string]int{"Hello": 2, "world": 1})
+}
+
+// Значения из одного слова учитываются, строки без поля и некорректные пропускаются
+func TestJSONLSingleWordValues(t *testing.T) {
+	var logged []string
+	opts := Options{}
+	opts.Processor.JSONField = ".meta.body"
+	opts.Processor.Log = func(message string) { logged = append(logged, message) }
+	content := `{"meta":{"body":"Привет"}}
+{"meta":{"body":"Привет мир"},"url":"http://example.com"}
+{"text":"пропущено"}
+{"meta":"не объект"}
+не JSON
+{"meta":{"body":"строка\nслово"}}
+`
+	vocab := buildTestFile(t, opts, "data.jsonl", content)
+	checkCounts(t, vocab, map[string]int{"Привет": 2, "мир": 1, "строка": 1, "слово": 1, "пропущено": 0, "объект": 0, "JSON": 0, "url": 0, "example": 0})
+	if len(logged) != 1 || !strings.Contains(logged[0], "Skipped 3/6 lines") {
+		t.Errorf("logged %q, want one message about 3/6 skipped lines", logged)
+	}
+}
+
+// Заголовки и пункты списков из одного слова учитываются
+func TestMarkdownSingleWordBlocks(t *testing.T) {
+	vocab := buildTestFile(t, Options{}, "notes.md", "# Introduction\n\n- apples\n- apples and pears\n\n## Summary\n")
+	checkCounts(t, vocab, map[string]int{"Introduction": 1, "apples": 2, "pears": 1, "Summary": 1, "#": 0, "-": 0})
+}
+
+const markdownWithCode = "# Usage\n\nCall run first\n\n```\nrun\nreturn value\n```\n\nThen `stop`\n"
+
+// Код пропускается, текст вокруг него учитывается
+func TestMarkdownExcludeCode(t *testing.T) {
+	opts := Options{}
+	opts.Processor.Code = processor.CodeExclude
+	vocab := buildTestFile(t, opts, "usage.md", markdownWithCode)
+	checkCounts(t, vocab, map[string]int{"Usage": 1, "Call": 1, "run": 1, "first": 1, "Then": 1, "return": 0, "value": 0, "stop": 0})
+}
+
+// Строки кода из одного слова попадают в словарь кода
+func TestMarkdownSeparateCode(t *testing.T) {
+	opts := Options{}
+	opts.Processor.Code = processor.CodeSeparate
+	path := filepath.Join(t.TempDir(), "usage.md")
+	if err := os.WriteFile(path, []byte(markdownWithCode), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tok := newTestTokenizer(t, opts)
+	vocab, err := tok.BuildFileVocabulary(path)
+	if err != nil {
+		t.Fatalf("BuildFileVocabulary: %v", err)
+	}
+	checkCounts(t, vocab, map[string]int{"Usage": 1, "run": 1, "Then": 1, "return": 0, "stop": 0})
+	checkCounts(t, tok.CodeVocabulary(), map[string]int{"run": 1, "return": 1, "value": 1, "stop": 1, "Call": 0})
+}
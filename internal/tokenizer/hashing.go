@@ -0,0 +1,113 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// Параметры FNV-1a (64 бита)
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Хеширование токенов в HashBuckets корзин: в словаре вместо токена учитывается
+// номер его корзины, поэтому размер словаря не превышает число корзин
+type hashBuckets struct {
+	n    uint64
+	seed uint64                   // Начальное состояние хеша с учетом Seed
+	rep  []atomic.Pointer[string] // Наименьший по алфавиту токен каждой корзины (при HashTokens)
+}
+
+func newHashBuckets(n int, seed int64, representatives bool) *hashBuckets {
+	h := &hashBuckets{n: uint64(n), seed: seedHash(seed)}
+	if representatives {
+		h.rep = make([]atomic.Pointer[string], n)
+	}
+	return h
+}
+
+// Начальное состояние FNV-1a с учетом Seed: хеши зависят от Seed
+func seedHash(seed int64) uint64 {
+	sum := uint64(fnvOffset64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+	for _, c := range buf {
+		sum = (sum ^ uint64(c)) * fnvPrime64
+	}
+	return sum
+}
+
+// Номер корзины токена: FNV-1a от Seed и байтов токена по модулю числа корзин
+func (h *hashBuckets) bucket(token string) uint64 {
+	sum := h.seed
+	for i := 0; i < len(token); i++ {
+		sum = (sum ^ uint64(token[i])) * fnvPrime64
+	}
+	return sum % h.n
+}
+
+// Запоминание токена как представителя корзины, если он меньше текущего по алфавиту.
+// Выбор не зависит от порядка обработки, поэтому результат воспроизводим.
+func (h *hashBuckets) remember(bucket uint64, token string) {
+	slot := &h.rep[bucket]
+	for {
+		current := slot.Load()
+		if current != nil && *current <= token {
+			return
+		}
+		if slot.CompareAndSwap(current, &token) {
+			return
+		}
+	}
+}
+
+// Замена токена номером его корзины (без HashBuckets токен не изменяется)
+func (t *Tokenizer) hashToken(token string) string {
+	h := t.buckets
+	if h == nil {
+		return token
+	}
+	bucket := h.bucket(token)
+	if h.rep != nil {
+		h.remember(bucket, token)
+	}
+	return strconv.FormatUint(bucket, 10)
+}
+
+// SaveHashTokens сохраняет строки "корзина токен" с представителем каждой непустой корзины
+// словаря — наименьшим по алфавиту токеном, попавшим в нее. Требует HashTokens.
+func (t *Tokenizer) SaveHashTokens(vocab map[string]int, outputFile string) error {
+	if t.buckets == nil || t.buckets.rep == nil {
+		return fmt.Errorf("bucket tokens were not tracked (HashTokens is not set)")
+	}
+	buckets := make([]uint64, 0, len(vocab))
+	for label := range vocab {
+		if bucket, err := strconv.ParseUint(label, 10, 64); err == nil && bucket < t.buckets.n {
+			buckets = append(buckets, bucket)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, bucket := range buckets {
+		if token := t.buckets.rep[bucket].Load(); token != nil {
+			fmt.Fprintf(file, "%d %s\n", bucket, *token)
+		}
+	}
+
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
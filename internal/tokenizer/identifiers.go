@@ -0,0 +1,63 @@
+package tokenizer
+
+import (
+	"unicode"
+
+	seg "github.com/terratensor/segment/segment"
+)
+
+// Разбиение идентификатора на слова по подчеркиваниям и сменам регистра
+// (getUserName -> get, User, Name; HTTPServer -> HTTP, Server; user_name -> user, name).
+// Цифры остаются в составе слова (utf8Decoder -> utf8, Decoder), а при splitDigits
+// выделяются отдельно (utf, 8, Decoder). Токены без букв и цифр не изменяются.
+func splitIdentifier(token seg.Segment, splitDigits bool) []seg.Segment {
+	runes := []rune(token.Text)
+	var parts []seg.Segment
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			parts = append(parts, seg.Segment{
+				Text:  string(runes[start:end]),
+				Start: token.Start + start,
+				End:   token.Start + end,
+			})
+			start = -1
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' {
+			flush(i)
+			continue
+		}
+		if start >= 0 && identifierBoundary(runes, i, splitDigits) {
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	if len(parts) == 0 {
+		return []seg.Segment{token}
+	}
+	return parts
+}
+
+// Проверка, начинается ли перед руной i новое слово идентификатора
+func identifierBoundary(runes []rune, i int, splitDigits bool) bool {
+	prev, r := runes[i-1], runes[i]
+	if splitDigits && unicode.IsDigit(prev) != unicode.IsDigit(r) {
+		return true
+	}
+	if !unicode.IsUpper(r) {
+		return false
+	}
+	// aB, 8B: заглавная после строчной буквы или цифры
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	// HTTPServer: последняя заглавная аббревиатуры начинает новое слово
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
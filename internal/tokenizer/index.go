@@ -0,0 +1,136 @@
+package tokenizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничение числа позиций на токен по умолчанию
+const defaultIndexLimit = 100
+
+// Позиция токена: файл, номер строки (с 1) и смещение в байтах от начала строки
+type posting struct {
+	file   string
+	line   int
+	offset int
+}
+
+// Позиции токена
+type tokenPostings struct {
+	positions []posting // Первые позиции, не больше IndexLimit
+	total     int       // Общее число вхождений
+}
+
+// Ограничение числа позиций на токен
+func (t *Tokenizer) indexLimit() int {
+	if t.opts.IndexLimit <= 0 {
+		return defaultIndexLimit
+	}
+	return t.opts.IndexLimit
+}
+
+// Смещения рун строки в байтах (последний элемент — длина строки)
+func runeOffsets(line string) []int {
+	offsets := make([]int, 0, utf8.RuneCountInString(line)+1)
+	for i := range line {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(line))
+}
+
+// Учет позиции токена в результате обработки файла
+func (t *Tokenizer) addPosting(result *fileResult, token string, p posting) {
+	if result.postings == nil {
+		result.postings = make(map[string]*tokenPostings)
+	}
+	key := t.foldKey(token)
+	tp, ok := result.postings[key]
+	if !ok {
+		tp = &tokenPostings{}
+		result.postings[key] = tp
+	}
+	tp.total++
+	if len(tp.positions) < t.indexLimit() {
+		tp.positions = append(tp.positions, p)
+	}
+}
+
+// Добавление позиций файла к общему индексу
+func (t *Tokenizer) recordPostings(result *fileResult) {
+	limit := t.indexLimit()
+
+	t.indexMutex.Lock()
+	defer t.indexMutex.Unlock()
+	if t.postings == nil {
+		t.postings = make(map[string]*tokenPostings)
+	}
+	for key, local := range result.postings {
+		tp, ok := t.postings[key]
+		if !ok {
+			tp = &tokenPostings{}
+			t.postings[key] = tp
+		}
+		tp.total += local.total
+		if free := limit - len(tp.positions); free > 0 {
+			if free > len(local.positions) {
+				free = len(local.positions)
+			}
+			tp.positions = append(tp.positions, local.positions[:free]...)
+		}
+	}
+}
+
+// SaveIndex сохраняет позиции токенов словаря (обратный индекс), собранные при обработке
+// директории. Каждая строка: токен, число вхождений и позиции "файл:строка:смещение"
+// через табуляцию; если список обрезан по IndexLimit, в конце добавляется "...".
+func (t *Tokenizer) SaveIndex(vocab map[string]int, outputFile string) error {
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, token := range tokens {
+		tp, ok := t.postings[t.foldKey(token)]
+		if !ok {
+			continue
+		}
+		positions := append([]posting(nil), tp.positions...)
+		sort.Slice(positions, func(i, j int) bool {
+			a, b := positions[i], positions[j]
+			if a.file != b.file {
+				return a.file < b.file
+			}
+			if a.line != b.line {
+				return a.line < b.line
+			}
+			return a.offset < b.offset
+		})
+
+		fields := make([]string, 0, len(positions)+3)
+		fields = append(fields, token, fmt.Sprint(tp.total))
+		for _, p := range positions {
+			fields = append(fields, fmt.Sprintf("%s:%d:%d", filepath.ToSlash(p.file), p.line, p.offset))
+		}
+		if tp.total > len(tp.positions) {
+			fields = append(fields, "...")
+		}
+		file.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
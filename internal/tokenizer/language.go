@@ -0,0 +1,100 @@
+package tokenizer
+
+import "strings"
+
+// Язык файлов, для которых определение не дало достаточной уверенности
+const UnknownLanguage = "unknown"
+
+// Порог уверенности определения языка по умолчанию
+const defaultLangConfidence = 0.8
+
+// Частые служебные слова языков (коды ISO 639-1). Язык текста определяется по тому,
+// служебные слова какого языка в нем встречаются чаще. Слова, общие для нескольких
+// языков (de, la, en), при определении не учитываются.
+var languageWords = map[string][]string{
+	"ru": {"и", "что", "он", "она", "они", "как", "это", "но", "же", "бы", "от", "все", "только",
+		"было", "был", "были", "уже", "или", "если", "когда", "еще", "ещё", "есть", "нет", "тоже",
+		"чтобы", "который", "которые", "меня", "при", "где", "этот", "очень", "с", "к", "мы", "его"},
+	"uk": {"і", "та", "що", "це", "як", "але", "від", "він", "вона", "вони", "ви", "його", "їх",
+		"був", "була", "було", "буде", "також", "який", "яка", "які", "коли", "тому", "щоб", "мене",
+		"тільки", "вже", "ще", "є", "немає", "або", "якщо", "цей", "дуже", "між", "з", "із"},
+	"en": {"the", "and", "of", "to", "is", "that", "it", "for", "are", "with", "as", "his", "they",
+		"be", "at", "one", "have", "this", "from", "or", "had", "by", "but", "not", "what", "all",
+		"were", "we", "when", "your", "can", "there", "which", "their", "would", "been", "has", "she", "he"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "von", "mit",
+		"sich", "auf", "für", "im", "dem", "auch", "werden", "aus", "er", "hat", "dass", "sie",
+		"nach", "wird", "bei", "noch", "wie", "einem", "über", "einen", "zum", "aber", "oder", "sind", "wurde"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "en", "un", "une", "du", "que", "qui", "dans",
+		"pour", "pas", "au", "sur", "ne", "se", "ce", "il", "elle", "sont", "avec", "plus", "par",
+		"mais", "ou", "nous", "vous", "ont", "été", "cette", "leur", "aux", "son", "sa"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "del", "se", "las", "por", "un", "para", "con",
+		"no", "una", "su", "al", "lo", "como", "más", "pero", "sus", "ya", "este", "porque", "esta",
+		"entre", "cuando", "muy", "sin", "sobre", "también", "hasta", "hay", "donde", "desde", "todo"},
+}
+
+// Служебное слово → язык; слова нескольких языков исключаются
+var languageByWord = func() map[string]string {
+	byWord := make(map[string]string)
+	shared := make(map[string]bool)
+	for lang, words := range languageWords {
+		for _, word := range words {
+			if other, ok := byWord[word]; ok && other != lang {
+				shared[word] = true
+			}
+			byWord[word] = lang
+		}
+	}
+	for word := range shared {
+		delete(byWord, word)
+	}
+	return byWord
+}()
+
+// DetectLanguage определяет язык словаря по частотам служебных слов языков.
+// Возвращает код языка ISO 639-1 (ru, uk, en, de, fr, es) и долю служебных слов
+// этого языка среди всех найденных служебных слов. Если служебных слов нет,
+// возвращается UnknownLanguage.
+func DetectLanguage(vocab map[string]int) (string, float64) {
+	counts := make(map[string]int)
+	total := 0
+	for token, count := range vocab {
+		if lang, ok := languageByWord[strings.ToLower(token)]; ok {
+			counts[lang] += count
+			total += count
+		}
+	}
+	if total == 0 {
+		return UnknownLanguage, 0
+	}
+
+	best := ""
+	for lang, count := range counts {
+		if best == "" || count > counts[best] || count == counts[best] && lang < best {
+			best = lang
+		}
+	}
+	return best, float64(counts[best]) / float64(total)
+}
+
+// BuildLanguageVocabularies строит отдельный словарь для каждого языка, определяя
+// язык каждого файла. Файлы с уверенностью ниже порога попадают в группу UnknownLanguage.
+func (t *Tokenizer) BuildLanguageVocabularies(dirPath string, maxGoroutines int) (map[string]map[string]int, error) {
+	threshold := t.opts.LangConfidence
+	if threshold <= 0 {
+		threshold = defaultLangConfidence
+	}
+
+	vocabs, err := t.buildVocabularies(dirPath, maxGoroutines, func(localVocab map[string]int) string {
+		lang, confidence := DetectLanguage(localVocab)
+		if confidence < threshold {
+			return UnknownLanguage
+		}
+		return lang
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delete(vocabs, "")
+	return vocabs, nil
+}
@@ -0,0 +1,70 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Словарь текста с частотами слов
+func wordCounts(text string) map[string]int {
+	vocab := make(map[string]int)
+	for _, word := range strings.Fields(text) {
+		vocab[word]++
+	}
+	return vocab
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Он сказал, что это было очень давно , и она тоже помнит , где они жили", "ru"},
+		{"Він сказав , що це було дуже давно , і вона також пам'ятає , де вони жили", "uk"},
+		{"He said that it was a long time ago and she remembers where they lived", "en"},
+		{"Er sagte , dass es lange her ist und sie sich erinnert , wo sie wohnten", "de"},
+		{"Il a dit que cette maison est dans la ville et elle se souvient des rues", "fr"},
+		{"Dijo que la casa está en el pueblo y que los niños juegan con sus amigos", "es"},
+		{"2019 2020 <NUM> ...", UnknownLanguage},
+	}
+	for _, tt := range tests {
+		if got, _ := DetectLanguage(wordCounts(tt.text)); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	// Общие слова нескольких языков не влияют на уверенность
+	if lang, confidence := DetectLanguage(wordCounts("de la de la the and")); lang != "en" || confidence != 1 {
+		t.Errorf("DetectLanguage with shared words = %q, %v, want en, 1", lang, confidence)
+	}
+	if lang, confidence := DetectLanguage(wordCounts("the and of и что")); lang != "en" || confidence != 0.6 {
+		t.Errorf("DetectLanguage of mixed text = %q, %v, want en, 0.6", lang, confidence)
+	}
+}
+
+func TestBuildLanguageVocabularies(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ru.txt":    "Он сказал, что это было давно, и она тоже помнит.\n",
+		"en.txt":    "He said that it was long ago, and she remembers.\n",
+		"mixed.txt": "the and of it и что он она\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tok := newTestTokenizer(t, Options{Lowercase: true})
+	vocabs, err := tok.BuildLanguageVocabularies(dir, 2)
+	if err != nil {
+		t.Fatalf("BuildLanguageVocabularies: %v", err)
+	}
+	if len(vocabs) != 3 {
+		t.Errorf("languages = %d, want ru, en and unknown: %v", len(vocabs), vocabs)
+	}
+	checkCounts(t, vocabs["ru"], map[string]int{"сказал": 1, "помнит": 1, "said": 0})
+	checkCounts(t, vocabs["en"], map[string]int{"said": 1, "remembers": 1, "сказал": 0})
+	checkCounts(t, vocabs[UnknownLanguage], map[string]int{"the": 1, "что": 1})
+}
@@ -0,0 +1,49 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Загрузка словаря лемм из файла (строки "словоформа лемма")
+func loadLemmaDict(filePath string, lowercase bool) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lemma dictionary: %v", err)
+	}
+	defer file.Close()
+
+	lemmas := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue // Пропускаем некорректные строки
+		}
+		form, lemma := fields[0], fields[1]
+		if lowercase {
+			form, lemma = strings.ToLower(form), strings.ToLower(lemma)
+		}
+		lemmas[form] = lemma
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading lemma dictionary: %v", err)
+	}
+
+	return lemmas, nil
+}
+
+// Приведение токена к лемме. Токены, которых нет в словаре, возвращаются без изменений.
+// Словоформы с заглавной буквы ищутся также в нижнем регистре.
+func (t *Tokenizer) lemmatize(token string) string {
+	if lemma, ok := t.lemmas[token]; ok {
+		return lemma
+	}
+	if lemma, ok := t.lemmas[strings.ToLower(token)]; ok {
+		return lemma
+	}
+	return token
+}
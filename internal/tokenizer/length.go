@@ -0,0 +1,41 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// SaveLengthStats сохраняет распределение длин токенов в рунах (строки "длина типы вхождения"):
+// для каждой длины — число разных токенов и их суммарная частота, по возрастанию длины.
+func (t *Tokenizer) SaveLengthStats(vocab map[string]int, outputFile string) error {
+	fmt.Fprintln(t.out, "Saving token length statistics...")
+	types := make(map[int]int)
+	occurrences := make(map[int]int)
+	for token, count := range vocab {
+		length := utf8.RuneCountInString(token)
+		types[length]++
+		occurrences[length] += count
+	}
+	lengths := make([]int, 0, len(types))
+	for length := range types {
+		lengths = append(lengths, length)
+	}
+	sort.Ints(lengths)
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, length := range lengths {
+		fmt.Fprintf(file, "%d %d %d\n", length, types[length], occurrences[length])
+	}
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package tokenizer
+
+import "fmt"
+
+// Учет токенов обработанного файла для MaxTokens. Счетчик общий для всех горутин
+// и изменяется атомарно, без блокировки.
+func (t *Tokenizer) countTokensRead(result *fileResult) {
+	if t.opts.MaxTokens <= 0 {
+		return
+	}
+	t.tokensRead.Add(int64(TotalCount(result.vocab)))
+}
+
+// Достигнут ли предел MaxTokens; после этого новые файлы не обрабатываются
+func (t *Tokenizer) tokenLimitReached() bool {
+	return t.opts.MaxTokens > 0 && t.tokensRead.Load() >= t.opts.MaxTokens
+}
+
+// Сообщение о том, что обработка остановлена по MaxTokens до конца корпуса
+func (t *Tokenizer) printTruncated(processed int) {
+	fmt.Fprintf(t.out, "Token limit reached: stopped after %d tokens in %d files (-max-tokens %d); the vocabulary is truncated\n",
+		t.tokensRead.Load(), processed, t.opts.MaxTokens)
+}
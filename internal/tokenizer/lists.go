@@ -0,0 +1,50 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Загрузка списка токенов из файла (по одному токену на строку)
+func loadTokenSet(filePath string, lowercase bool) (map[string]struct{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening token list: %v", err)
+	}
+	defer file.Close()
+
+	tokens := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		token := strings.TrimSpace(scanner.Text())
+		if token == "" {
+			continue
+		}
+		if lowercase {
+			token = strings.ToLower(token)
+		}
+		tokens[token] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading token list: %v", err)
+	}
+
+	return tokens, nil
+}
+
+// Проверка токена по белому и черному спискам.
+// Сначала применяется белый список, затем из оставшихся удаляются токены черного списка.
+func (t *Tokenizer) listed(token string) bool {
+	if t.whitelist != nil {
+		if _, ok := t.whitelist[token]; !ok {
+			return false
+		}
+	}
+	if _, ok := t.blacklist[token]; ok {
+		return false
+	}
+	return true
+}
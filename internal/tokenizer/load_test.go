@@ -0,0 +1,93 @@
+package tokenizer
+
+import (
+	"compress/gzip"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestVocabulary(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeGzipVocabulary(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(file)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Частоты повторяющегося токена суммируются, а не перезаписываются
+func TestLoadVocabularySumsDuplicates(t *testing.T) {
+	path := writeTestVocabulary(t, "кот 3\nпес 1\nкот 4\n")
+	tok := newTestTokenizer(t, Options{})
+	vocab, err := tok.LoadVocabulary(path)
+	if err != nil {
+		t.Fatalf("LoadVocabulary: %v", err)
+	}
+	if vocab["кот"] != 7 || vocab["пес"] != 1 || len(vocab) != 2 {
+		t.Errorf("LoadVocabulary = %v, want map[кот:7 пес:1]", vocab)
+	}
+}
+
+func TestLoadVocabularyDuplicatePolicies(t *testing.T) {
+	path := writeTestVocabulary(t, "кот 3\nкот 4\n")
+	for policy, want := range map[string]int{DuplicatesSum: 7, DuplicatesFirst: 3, DuplicatesLast: 4} {
+		tok := newTestTokenizer(t, Options{Duplicates: policy})
+		vocab, err := tok.LoadVocabulary(path)
+		if err != nil {
+			t.Fatalf("LoadVocabulary with %s: %v", policy, err)
+		}
+		if vocab["кот"] != want {
+			t.Errorf("LoadVocabulary with %s: кот = %d, want %d", policy, vocab["кот"], want)
+		}
+	}
+
+	tok := newTestTokenizer(t, Options{Duplicates: DuplicatesError})
+	if _, err := tok.LoadVocabulary(path); err == nil {
+		t.Errorf("LoadVocabulary with %s: no error for duplicate token", DuplicatesError)
+	}
+}
+
+// Словари .gz распаковываются и объединяются вместе с обычными
+func TestMergeVocabulariesPlainAndGzip(t *testing.T) {
+	plain := writeTestVocabulary(t, "кот 3\nпес 1\n")
+	compressed := writeGzipVocabulary(t, "кот 4\nмышь 2\n")
+	tok := newTestTokenizer(t, Options{})
+
+	loaded, err := tok.LoadVocabulary(compressed)
+	if err != nil {
+		t.Fatalf("LoadVocabulary(%s): %v", compressed, err)
+	}
+	if want := map[string]int{"кот": 4, "мышь": 2}; !maps.Equal(loaded, want) {
+		t.Errorf("LoadVocabulary(%s) = %v, want %v", compressed, loaded, want)
+	}
+
+	merged, err := tok.MergeVocabularies([]string{plain, compressed})
+	if err != nil {
+		t.Fatalf("MergeVocabularies: %v", err)
+	}
+	if want := map[string]int{"кот": 7, "пес": 1, "мышь": 2}; !maps.Equal(merged, want) {
+		t.Errorf("MergeVocabularies = %v, want %v", merged, want)
+	}
+}
@@ -0,0 +1,132 @@
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Этапы обработки, длительность которых учитывается в метриках
+const (
+	StageFile  = "file"  // Обработка одного файла
+	StageBuild = "build" // Построение словаря директории
+	StageSave  = "save"  // Сохранение словаря
+)
+
+// Границы корзин гистограммы длительностей этапов в секундах
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// Гистограмма длительностей в формате Prometheus (счетчики корзин не накопительные)
+type histogram struct {
+	counts []uint64 // Число наблюдений по корзинам durationBuckets и +Inf
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets)+1)
+	}
+	i := sort.SearchFloat64s(durationBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// metrics — счетчики обработки для мониторинга (-metrics-addr).
+// Обновляются один раз на файл или этап, поэтому достаточно одной блокировки.
+type metrics struct {
+	mu        sync.Mutex
+	files     uint64            // Успешно обработанные файлы
+	failures  map[string]uint64 // Файлы, которые не удалось обработать, по видам ошибок
+	tokens    uint64            // Подсчитанные токены
+	bytes     uint64            // Размер обработанных файлов
+	durations map[string]*histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{failures: make(map[string]uint64), durations: make(map[string]*histogram)}
+}
+
+// Учет успешно обработанного файла размером size байт
+func (m *metrics) fileProcessed(result *fileResult, size int64) {
+	tokens := 0
+	for _, count := range result.vocab {
+		tokens += count
+	}
+	m.mu.Lock()
+	m.files++
+	m.tokens += uint64(tokens)
+	m.bytes += uint64(size)
+	m.mu.Unlock()
+}
+
+func (m *metrics) fileFailed(kind string) {
+	m.mu.Lock()
+	m.failures[kind]++
+	m.mu.Unlock()
+}
+
+// Учет длительности этапа, начавшегося в start
+func (m *metrics) observe(stage string, start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.durations[stage]
+	if !ok {
+		h = &histogram{}
+		m.durations[stage] = h
+	}
+	h.observe(time.Since(start).Seconds())
+}
+
+// WriteMetrics записывает метрики обработки в текстовом формате Prometheus
+func (t *Tokenizer) WriteMetrics(w io.Writer) error {
+	m := t.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b []byte
+	add := func(format string, args ...interface{}) {
+		b = fmt.Appendf(b, format, args...)
+	}
+
+	add("# HELP vocab_files_processed_total Files processed successfully.\n")
+	add("# TYPE vocab_files_processed_total counter\n")
+	add("vocab_files_processed_total %d\n", m.files)
+
+	add("# HELP vocab_file_errors_total Files that could not be processed, by error kind.\n")
+	add("# TYPE vocab_file_errors_total counter\n")
+	for _, kind := range []string{FailureOpen, FailureFormat, FailureRead, FailureTimeout} {
+		add("vocab_file_errors_total{kind=%q} %d\n", kind, m.failures[kind])
+	}
+
+	add("# HELP vocab_tokens_total Tokens counted in processed files.\n")
+	add("# TYPE vocab_tokens_total counter\n")
+	add("vocab_tokens_total %d\n", m.tokens)
+
+	add("# HELP vocab_bytes_read_total Size of processed files in bytes.\n")
+	add("# TYPE vocab_bytes_read_total counter\n")
+	add("vocab_bytes_read_total %d\n", m.bytes)
+
+	add("# HELP vocab_stage_duration_seconds Duration of processing stages.\n")
+	add("# TYPE vocab_stage_duration_seconds histogram\n")
+	for _, stage := range []string{StageFile, StageBuild, StageSave} {
+		h, ok := m.durations[stage]
+		if !ok {
+			h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		}
+		var cumulative uint64
+		for i, bound := range durationBuckets {
+			cumulative += h.counts[i]
+			add("vocab_stage_duration_seconds_bucket{stage=%q,le=\"%g\"} %d\n", stage, bound, cumulative)
+		}
+		add("vocab_stage_duration_seconds_bucket{stage=%q,le=\"+Inf\"} %d\n", stage, h.count)
+		add("vocab_stage_duration_seconds_sum{stage=%q} %g\n", stage, h.sum)
+		add("vocab_stage_duration_seconds_count{stage=%q} %d\n", stage, h.count)
+	}
+
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,31 @@
+package tokenizer
+
+import "strings"
+
+// Порядок полей в строках входного словаря
+const (
+	InputOrderTokenCount = "token-count" // "токен частота"
+	InputOrderCountToken = "count-token" // "частота токен"
+)
+
+// Проверка порядка полей входного словаря
+func validInputOrder(order string) bool {
+	switch order {
+	case "", InputOrderTokenCount, InputOrderCountToken:
+		return true
+	}
+	return false
+}
+
+// Разбор строки словаря на токен и частоту с учетом порядка полей InputOrder.
+// Возвращает число полей строки; токен и частота заполняются, только если полей два.
+func (t *Tokenizer) splitEntry(line string) (token, count string, fields int) {
+	parts := strings.Split(line, " ")
+	if len(parts) != 2 {
+		return "", "", len(parts)
+	}
+	if t.opts.InputOrder == InputOrderCountToken {
+		return parts[1], parts[0], 2
+	}
+	return parts[0], parts[1], 2
+}
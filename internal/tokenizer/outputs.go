@@ -0,0 +1,125 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SaveVocabularyJSON сохраняет словарь JSON-объектом {"токен": частота} в порядке sortType
+// (freq, alpha, firstseen или без сортировки). Такой файл загружается обратно через -input и -inputs.
+func (t *Tokenizer) SaveVocabularyJSON(vocab map[string]int, outputFile string, sortType string) error {
+	fmt.Fprintln(t.out, "Saving JSON vocabulary...")
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+	switch sortType {
+	case "freq":
+		sort.Slice(tokens, func(i, j int) bool {
+			if vocab[tokens[i]] != vocab[tokens[j]] {
+				return vocab[tokens[i]] > vocab[tokens[j]]
+			}
+			return tokens[i] < tokens[j]
+		})
+	case "alpha":
+		sort.Strings(tokens)
+	case SortFirstSeen:
+		sort.Slice(tokens, func(i, j int) bool { return t.firstSeenLess(tokens[i], tokens[j]) })
+	}
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	w := bufio.NewWriter(file)
+	w.WriteString("{")
+	for i, token := range tokens {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		fmt.Fprintf(w, "\n  %s: %d", jsonString(token), vocab[token])
+	}
+	w.WriteString("\n}\n")
+	if err := w.Flush(); err != nil {
+		t.logError(fmt.Sprintf("Error writing output file %s: %v", outputFile, err))
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
+
+// Строка в кавычках JSON без экранирования <, > и &
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// SaveStats сохраняет сводную статистику словаря строками "показатель значение":
+// число разных токенов (types), общее число вхождений (tokens), число токенов,
+// встретившихся один раз (hapax), наибольшую и среднюю частоту и отношение types/tokens.
+// При HumanCounts числа от тысячи записываются сокращенно (1.2M).
+func (t *Tokenizer) SaveStats(vocab map[string]int, outputFile string) error {
+	fmt.Fprintln(t.out, "Saving vocabulary statistics...")
+	total, hapax, maxCount := 0, 0, 0
+	for _, count := range vocab {
+		total += count
+		if count == 1 {
+			hapax++
+		}
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	meanCount, typeTokenRatio := 0.0, 0.0
+	if len(vocab) > 0 {
+		meanCount = float64(total) / float64(len(vocab))
+	}
+	if total > 0 {
+		typeTokenRatio = float64(len(vocab)) / float64(total)
+	}
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	fmt.Fprintf(file, "types %s\n", t.statCount(len(vocab)))
+	fmt.Fprintf(file, "tokens %s\n", t.statCount(total))
+	fmt.Fprintf(file, "hapax %s\n", t.statCount(hapax))
+	fmt.Fprintf(file, "max_count %s\n", t.statCount(maxCount))
+	fmt.Fprintf(file, "mean_count %.4f\n", meanCount)
+	fmt.Fprintf(file, "type_token_ratio %.6f\n", typeTokenRatio)
+	if t.opts.DocFreq {
+		fmt.Fprintf(file, "documents %s\n", t.statCount(t.documents))
+	}
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
+
+// Запись числа в статистике: сокращенно при HumanCounts
+func (t *Tokenizer) statCount(n int) string {
+	if t.opts.HumanCounts {
+		if text, ok := humanCount(float64(n)); ok {
+			return text
+		}
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,122 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Теги частей речи (Universal Dependencies) для токенов, которых нет в словаре тегов
+const (
+	PosNumber      = "NUM"
+	PosPunctuation = "PUNCT"
+	PosUnknown     = "X"
+)
+
+// Разделитель токена и тега при PosTags ("дом_NOUN")
+const posTagSeparator = "_"
+
+// Загрузка словаря частей речи из файла (строки "словоформа ТЕГ"). Для словоформы
+// с несколькими тегами используется первый из них, поэтому неоднозначные словоформы
+// нужно записывать с самым частым тегом раньше остальных.
+func loadPosDict(filePath string, lowercase bool) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening part-of-speech dictionary: %v", err)
+	}
+	defer file.Close()
+
+	tags := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue // Пропускаем некорректные строки
+		}
+		form, tag := fields[0], strings.ToUpper(fields[1])
+		if lowercase {
+			form = strings.ToLower(form)
+		}
+		if _, ok := tags[form]; !ok {
+			tags[form] = tag
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading part-of-speech dictionary: %v", err)
+	}
+
+	return tags, nil
+}
+
+// Разбор списка тегов PosFilter через запятую (пустой список — все теги)
+func parsePosFilter(list string) map[string]struct{} {
+	if list == "" {
+		return nil
+	}
+	tags := make(map[string]struct{})
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.ToUpper(strings.TrimSpace(tag)); tag != "" {
+			tags[tag] = struct{}{}
+		}
+	}
+	return tags
+}
+
+// Часть речи токена по словарю. Словоформы с заглавной буквы ищутся также в нижнем
+// регистре. Токены, которых нет в словаре, — числа (NUM), пунктуация (PUNCT) или X.
+func (t *Tokenizer) posTag(token string) string {
+	if tag, ok := t.posTags[token]; ok {
+		return tag
+	}
+	if tag, ok := t.posTags[strings.ToLower(token)]; ok {
+		return tag
+	}
+	switch {
+	case isNumber(token) || token == t.opts.NumToken:
+		return PosNumber
+	case isPunctuation(token):
+		return PosPunctuation
+	}
+	return PosUnknown
+}
+
+// Преобразование с учетом части речи: тег определяется по словоформе до приведения
+// к лемме и основе (forms), токены с тегами не из PosFilter отбрасываются, а при PosTags
+// тег добавляется к результату
+func (t *Tokenizer) posFilter(forms []TokenFilter) TokenFilter {
+	return func(token string) (string, bool) {
+		tag := t.posTag(token)
+		if t.posAllowed != nil {
+			if _, ok := t.posAllowed[tag]; !ok {
+				return "", false
+			}
+		}
+		for _, form := range forms {
+			var ok bool
+			if token, ok = form(token); !ok {
+				return "", false
+			}
+		}
+		if t.opts.PosTags {
+			token += posTagSeparator + tag
+		}
+		return token, true
+	}
+}
+
+// Проверка, является ли токен числом: цифры, возможно с разделителями "." и "," ("3.14", "1,000")
+func isNumber(token string) bool {
+	digits := false
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			digits = true
+		case r != '.' && r != ',':
+			return false
+		}
+	}
+	return digits
+}
@@ -0,0 +1,90 @@
+package tokenizer
+
+import "sync"
+
+// Минимальное число токенов на одну горутину при параллельной обработке словаря:
+// для небольших словарей запуск горутин и слияние частей дороже самой обработки
+const minTokensPerWorker = 1 << 14
+
+// Число токенов, после обработки которого горутина обновляет прогресс
+const progressBatch = 4096
+
+// Обработка одного токена загруженного словаря: проверка UTF-8, цепочка фильтров, хеширование. Возвращает обработанный токен, false, если токен отброшен,
+// и true, если токен содержал некорректный UTF-8.
+func (t *Tokenizer) processEntry(token string) (string, bool, bool) {
+	token, ok, invalid := t.checkUTF8(token)
+	if !ok {
+		return "", false, invalid
+	}
+	token, ok = t.normalizeToken(token)
+	if !ok {
+		return "", false, invalid
+	}
+	return t.hashToken(token), true, invalid
+}
+
+// Число горутин для обработки словаря из size токенов (не больше MaxGoroutines)
+func (t *Tokenizer) vocabWorkers(size int) int {
+	workers := t.opts.MaxGoroutines
+	if limit := size / minTokensPerWorker; limit < workers {
+		workers = limit
+	}
+	return workers
+}
+
+// Параллельная обработка словаря: токены делятся на workers частей, каждая горутина
+// собирает свой словарь, затем словари частей суммируются. Результат совпадает
+// с последовательной обработкой. Возвращает словарь и число токенов с некорректным UTF-8.
+func processVocabularyParallel[C countValue](t *Tokenizer, vocab map[string]C, workers int, tokenProgress *progress) (map[string]C, int) {
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+
+	shards := make([]map[string]C, workers)
+	invalid := make([]int, workers)
+	var wg sync.WaitGroup
+	for w := range shards {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			part := tokens[len(tokens)*w/workers : len(tokens)*(w+1)/workers]
+			shard := make(map[string]C, len(part))
+			for i, original := range part {
+				if i > 0 && i%progressBatch == 0 {
+					tokenProgress.add(progressBatch)
+				}
+				token, ok, isInvalid := t.processEntry(original)
+				if isInvalid {
+					invalid[w]++
+				}
+				if ok {
+					shard[token] += vocab[original]
+				}
+			}
+			tokenProgress.add(len(part) - (len(part)-1)/progressBatch*progressBatch)
+			shards[w] = shard
+		}(w)
+	}
+	wg.Wait()
+
+	// Слияние в самый большой словарь части
+	largest := 0
+	for w, shard := range shards {
+		if len(shard) > len(shards[largest]) {
+			largest = w
+		}
+	}
+	processedVocab := shards[largest]
+	invalidTokens := 0
+	for w, shard := range shards {
+		invalidTokens += invalid[w]
+		if w == largest {
+			continue
+		}
+		for token, count := range shard {
+			processedVocab[token] += count
+		}
+	}
+	return processedVocab, invalidTokens
+}
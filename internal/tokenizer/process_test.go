@@ -0,0 +1,67 @@
+package tokenizer
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+)
+
+// Словарь для обработки: варианты регистра, пунктуация и некорректный UTF-8
+func testProcessVocabulary(size int) map[string]int {
+	vocab := make(map[string]int, size)
+	for i := 0; len(vocab) < size; i++ {
+		switch i % 4 {
+		case 0:
+			vocab[fmt.Sprintf("слово%d", i/4)] = i%7 + 1
+		case 1:
+			vocab[fmt.Sprintf("Слово%d", i/4)] = i%5 + 1
+		case 2:
+			vocab[fmt.Sprintf("%d,", i)] = 1
+		default:
+			vocab[fmt.Sprintf("bad\xff%d", i)] = 2
+		}
+	}
+	vocab["..."] = 10
+	return vocab
+}
+
+// Параллельная обработка словаря дает тот же результат, что и последовательная
+func TestProcessVocabularyParallelMatchesSequential(t *testing.T) {
+	vocab := testProcessVocabulary(8 * minTokensPerWorker)
+	opts := Options{Lowercase: true, FilterPunct: true, TrimPunct: true}
+
+	opts.MaxGoroutines = 1
+	sequential := newTestTokenizer(t, opts)
+	want := sequential.ProcessVocabulary(vocab)
+
+	opts.MaxGoroutines = 4
+	parallel := newTestTokenizer(t, opts)
+	if workers := parallel.vocabWorkers(len(vocab)); workers != 4 {
+		t.Fatalf("vocabWorkers(%d) = %d, want 4", len(vocab), workers)
+	}
+	got := parallel.ProcessVocabulary(vocab)
+
+	if !maps.Equal(got, want) {
+		t.Errorf("parallel result differs from sequential: %d and %d tokens", len(got), len(want))
+	}
+	if want["слово1"] == 0 || want["слово1"] == vocab["слово1"] {
+		t.Errorf("case variants were not merged: слово1 = %d", want["слово1"])
+	}
+}
+
+func BenchmarkProcessVocabulary(b *testing.B) {
+	vocab := testProcessVocabulary(1 << 18)
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			tok, err := NewTokenizer(Options{Lowercase: true, FilterPunct: true, MaxGoroutines: workers, ErrorMode: ErrorModeNone, Quiet: true})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer tok.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tok.ProcessVocabulary(vocab)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Интервал вывода прогресса по умолчанию, если stdout не является терминалом
+const nonTerminalProgressInterval = 10 * time.Second
+
+// Минимальный интервал перерисовки строки прогресса в терминале
+const terminalRedrawInterval = 100 * time.Millisecond
+
+// Ширина полосы прогресса в символах
+const progressBarWidth = 20
+
+// progress выводит ход выполнения этапа: выполненную долю, скорость и оценку оставшегося времени.
+// В терминале строка с полосой прогресса перерисовывается на месте (через \r) не чаще
+// terminalRedrawInterval и обрезается по ширине терминала; иначе выводятся отдельные строки
+// не чаще одного раза за интервал. Без интервала прогресс выводится с шагом в 1%.
+// Если общее число элементов выясняется по ходу работы (grow), до вызова complete
+// оно выводится со знаком "+" и без оценки оставшегося времени.
+// Методы безопасны для вызова из нескольких горутин.
+type progress struct {
+	label    string // Название этапа
+	unit     string // Единица измерения: files, tokens
+	total    int
+	growing  bool // Общее число элементов еще не известно окончательно
+	step     int
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	terminal bool
+	width    int // Ширина терминала
+	out      io.Writer
+
+	mu   sync.Mutex
+	done int
+}
+
+func (t *Tokenizer) newProgress(label, unit string, total int) *progress {
+	step := total / 100
+	if step <= 0 {
+		step = 1 // Минимальный шаг
+	}
+
+	interval := t.opts.ProgressInterval
+	if interval == 0 && !t.terminal {
+		interval = nonTerminalProgressInterval
+	}
+	if t.terminal && interval < terminalRedrawInterval {
+		interval = terminalRedrawInterval
+	}
+
+	now := time.Now()
+	return &progress{
+		label:    label,
+		unit:     unit,
+		total:    total,
+		step:     step,
+		interval: interval,
+		start:    now,
+		last:     now,
+		terminal: t.terminal,
+		width:    terminalWidth(t.out),
+		out:      t.out,
+	}
+}
+
+// add отмечает выполнение n элементов и при необходимости выводит прогресс
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.print("")
+}
+
+// grow увеличивает общее число элементов на n, пока оно не известно окончательно
+func (p *progress) grow(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total += n
+	p.growing = true
+	if step := p.total / 100; step > p.step {
+		p.step = step
+	}
+}
+
+// complete отмечает, что общее число элементов известно окончательно
+func (p *progress) complete() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.growing = false
+}
+
+// update выводит прогресс после обработки done элементов, если пришло время.
+// Необязательная заметка (например, имя текущего файла) выводится в конце строки.
+func (p *progress) update(done int, note string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = done
+	p.print(note)
+}
+
+func (p *progress) print(note string) {
+	if !p.due(p.done) {
+		return
+	}
+	line := p.line()
+	if rate := p.rate(); rate > 0 {
+		line += fmt.Sprintf(", %s %s/s", formatRate(rate), p.unit)
+	}
+	if !p.growing {
+		line += eta(p.start, p.done, p.total)
+	}
+	if note != "" {
+		line += ": " + note
+	}
+	if p.terminal {
+		// \033[K стирает остаток предыдущей, более длинной строки
+		fmt.Fprint(p.out, "\r"+p.fit(p.bar()+line)+"\033[K")
+	} else {
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+// finish выводит итоговую строку прогресса со временем выполнения этапа
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	line := p.line() + fmt.Sprintf(" in %s", time.Since(p.start).Round(time.Millisecond))
+	if p.terminal {
+		fmt.Fprint(p.out, "\r"+p.fit(p.bar()+line)+"\033[K\n")
+	} else {
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+// Основная часть строки прогресса: "Merging: 3/10 files (30%)"
+func (p *progress) line() string {
+	percent := 100
+	if p.total > 0 {
+		percent = p.done * 100 / p.total
+	}
+	if p.growing {
+		return fmt.Sprintf("%s: %d/%d+ %s", p.label, p.done, p.total, p.unit)
+	}
+	return fmt.Sprintf("%s: %d/%d %s (%d%%)", p.label, p.done, p.total, p.unit, percent)
+}
+
+// Полоса прогресса для терминала: "[=======>            ] "
+func (p *progress) bar() string {
+	filled := progressBarWidth
+	if p.total > 0 && p.done < p.total {
+		filled = p.done * progressBarWidth / p.total
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return "[" + bar + "] "
+}
+
+// Обрезка строки по ширине терминала, чтобы перенос строки не ломал перерисовку
+func (p *progress) fit(line string) string {
+	if p.width <= 0 || utf8.RuneCountInString(line) < p.width {
+		return line
+	}
+	return string([]rune(line)[:p.width-1])
+}
+
+// Скорость обработки: элементов в секунду с начала этапа
+func (p *progress) rate() float64 {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.done) / elapsed
+}
+
+// due сообщает, пора ли выводить прогресс после обработки done элементов
+func (p *progress) due(done int) bool {
+	if p.interval <= 0 {
+		return done%p.step == 0
+	}
+
+	now := time.Now()
+	if now.Sub(p.last) < p.interval {
+		return false
+	}
+	p.last = now
+	return true
+}
+
+// eta оценивает оставшееся время линейной экстраполяцией: среднее время
+// на элемент с момента start, умноженное на число оставшихся элементов
+func eta(start time.Time, done, total int) string {
+	if done <= 0 || done >= total {
+		return ""
+	}
+	remaining := time.Since(start) / time.Duration(done) * time.Duration(total-done)
+	return fmt.Sprintf(", ETA %s", remaining.Round(time.Second))
+}
+
+// Форматирование скорости: 950, 12.3k, 4.5M
+func formatRate(rate float64) string {
+	switch {
+	case rate >= 1e6:
+		return fmt.Sprintf("%.1fM", rate/1e6)
+	case rate >= 1e3:
+		return fmt.Sprintf("%.1fk", rate/1e3)
+	case rate >= 10:
+		return fmt.Sprintf("%.0f", rate)
+	}
+	return fmt.Sprintf("%.1f", rate)
+}
+
+// Ширина терминала, в который выводится out: размер окна терминала, а если его
+// не удалось узнать — переменная окружения COLUMNS (по умолчанию 80)
+func terminalWidth(out io.Writer) int {
+	if f, ok := out.(*os.File); ok {
+		if columns := ttyColumns(f.Fd()); columns > 0 {
+			return columns
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 80
+}
+
+// Проверка, подключен ли файл к терминалу: запрос настроек терминала ioctl выполняется
+// только для tty (в отличие от проверки os.ModeCharDevice, верной и для /dev/null)
+func isTerminal(f *os.File) bool {
+	return isTTY(f.Fd())
+}
@@ -0,0 +1,43 @@
+package tokenizer
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// /dev/null — символьное устройство, но не терминал
+func TestIsTerminalDevNull(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("isTerminal(%s) = true, want false", os.DevNull)
+	}
+}
+
+// Не терминал: ширина из COLUMNS, а без нее — 80
+func TestTerminalWidthFallback(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if columns := ttyColumns(f.Fd()); columns != 0 {
+		t.Errorf("ttyColumns(%s) = %d, want 0", os.DevNull, columns)
+	}
+
+	t.Setenv("COLUMNS", "132")
+	if width := terminalWidth(f); width != 132 {
+		t.Errorf("terminalWidth with COLUMNS=132 = %d, want 132", width)
+	}
+	t.Setenv("COLUMNS", "")
+	if width := terminalWidth(f); width != 80 {
+		t.Errorf("terminalWidth without COLUMNS = %d, want 80", width)
+	}
+	if width := terminalWidth(io.Discard); width != 80 {
+		t.Errorf("terminalWidth(io.Discard) = %d, want 80", width)
+	}
+}
@@ -0,0 +1,101 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ограничение списка файлов на токен по умолчанию
+const defaultProvenanceLimit = 20
+
+// Файлы, в которых встретился токен
+type tokenSources struct {
+	files []string // Первые файлы, не больше ProvenanceLimit
+	total int      // Общее число файлов
+}
+
+// Учет файла как источника токенов его словаря
+func (t *Tokenizer) recordProvenance(filePath string, localVocab map[string]int) {
+	if t.opts.ProvenanceAbs {
+		if absPath, err := filepath.Abs(filePath); err == nil {
+			filePath = absPath
+		}
+	}
+	limit := t.opts.ProvenanceLimit
+	if limit <= 0 {
+		limit = defaultProvenanceLimit
+	}
+
+	// Один файл может дать несколько вариантов написания одного токена
+	keys := make(map[string]struct{}, len(localVocab))
+	for token := range localVocab {
+		keys[t.foldKey(token)] = struct{}{}
+	}
+
+	t.sourcesMutex.Lock()
+	defer t.sourcesMutex.Unlock()
+	if t.sources == nil {
+		t.sources = make(map[string]*tokenSources)
+	}
+	for key := range keys {
+		s, ok := t.sources[key]
+		if !ok {
+			s = &tokenSources{}
+			t.sources[key] = s
+		}
+		s.total++
+		if len(s.files) < limit {
+			s.files = append(s.files, filePath)
+		}
+	}
+}
+
+// Ключ токена в таблицах источников и документных частот:
+// при объединении регистров варианты написания совпадают
+func (t *Tokenizer) foldKey(token string) string {
+	if t.opts.FoldCase {
+		return strings.ToLower(token)
+	}
+	return token
+}
+
+// SaveProvenance сохраняет источники токенов словаря, собранные при обработке директории.
+// Каждая строка: токен, число файлов и список файлов через табуляцию; если список
+// обрезан по ProvenanceLimit, в конце добавляется "...".
+func (t *Tokenizer) SaveProvenance(vocab map[string]int, outputFile string) error {
+	tokens := make([]string, 0, len(vocab))
+	for token := range vocab {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, token := range tokens {
+		s, ok := t.sources[t.foldKey(token)]
+		if !ok {
+			continue
+		}
+		files := append([]string(nil), s.files...)
+		sort.Strings(files)
+		fmt.Fprintf(writer, "%s\t%d\t%s", token, s.total, strings.Join(files, "\t"))
+		if s.total > len(s.files) {
+			writer.WriteString("\t...")
+		}
+		writer.WriteString("\n")
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
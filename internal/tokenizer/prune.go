@@ -0,0 +1,62 @@
+package tokenizer
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Доля MaxVocabSize, до которой сокращается словарь при вытеснении: запас места
+// позволяет не сокращать словарь после каждого файла
+const pruneTargetPercent = 75
+
+// Итоги вытеснения редких токенов при MaxVocabSize
+type pruneStats struct {
+	runs     int // Число сокращений словаря
+	removed  int // Число удаленных записей
+	maxError int // Наибольшее занижение частоты оставшегося токена: сумма порогов всех сокращений
+}
+
+// Вытеснение редких токенов из общего словаря, если он больше MaxVocabSize.
+// Удаляются токены с частотой не выше порога, при котором остается не больше
+// pruneTargetPercent процентов MaxVocabSize записей (токены с равной частотой удаляются
+// вместе, поэтому может остаться меньше). Удаленный токен, встретившийся снова, считается
+// заново, поэтому его частота занижается не больше чем на сумму порогов — так же, как
+// в алгоритме lossy counting. Вызывается под блокировкой словаря.
+func (t *Tokenizer) pruneVocabulary(vocab map[string]int) {
+	if t.opts.MaxVocabSize <= 0 || len(vocab) <= t.opts.MaxVocabSize {
+		return
+	}
+	target := max(t.opts.MaxVocabSize*pruneTargetPercent/100, 1)
+
+	counts := make([]int, 0, len(vocab))
+	for _, count := range vocab {
+		counts = append(counts, count)
+	}
+	slices.Sort(counts)
+	// Частоты выше порога есть не больше чем у target токенов
+	threshold := counts[len(counts)-target-1]
+
+	size := len(vocab)
+	for token, count := range vocab {
+		if count <= threshold {
+			delete(vocab, token)
+		}
+	}
+
+	t.pruneMutex.Lock()
+	t.pruned.runs++
+	t.pruned.removed += size - len(vocab)
+	t.pruned.maxError += threshold
+	t.pruneMutex.Unlock()
+}
+
+// Сообщение о том, что частоты приблизительны из-за вытеснения по MaxVocabSize
+func (t *Tokenizer) printPruned() {
+	t.pruneMutex.Lock()
+	defer t.pruneMutex.Unlock()
+	if t.pruned.runs == 0 {
+		return
+	}
+	fmt.Fprintf(t.out, "Vocabulary exceeded -max-vocab-size %d: pruned %d times, %d rare tokens evicted; counts may be underestimated by up to %d\n",
+		t.opts.MaxVocabSize, t.pruned.runs, t.pruned.removed, t.pruned.maxError)
+}
@@ -0,0 +1,97 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Категории, символы которых по умолчанию считаются пунктуацией: знаки препинания и символы
+var defaultPunctCategories = []*unicode.RangeTable{unicode.P, unicode.S}
+
+// punctSet задает символы, из которых состоят отбрасываемые токены пунктуации (PunctCategories)
+type punctSet struct {
+	include []*unicode.RangeTable // Категории и диапазоны, символы которых считаются пунктуацией
+	exclude []*unicode.RangeTable // Исключения из include (элементы спецификации с "!")
+}
+
+// Разбор спецификации категорий пунктуации: список через запятую из категорий Unicode
+// (P, Pd, Po, S, Sc, Sm, ...) и диапазонов кодов (U+00B7, U+2010-U+2015). Элемент
+// с префиксом "!" исключает символы из пунктуации. Если заданы только исключения,
+// они применяются к категориям по умолчанию (P и S). Пустая спецификация — nil.
+func parsePunctCategories(spec string) (*punctSet, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	set := &punctSet{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		exclude := strings.HasPrefix(item, "!")
+		item = strings.TrimPrefix(item, "!")
+
+		table, err := runeTable(item)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			set.exclude = append(set.exclude, table)
+		} else {
+			set.include = append(set.include, table)
+		}
+	}
+	if len(set.include) == 0 {
+		set.include = defaultPunctCategories
+	}
+	return set, nil
+}
+
+// Таблица символов для категории Unicode ("Sc") или диапазона кодов ("U+2010-U+2015")
+func runeTable(item string) (*unicode.RangeTable, error) {
+	if table, ok := unicode.Categories[item]; ok {
+		return table, nil
+	}
+	if !strings.HasPrefix(strings.ToUpper(item), "U+") {
+		return nil, fmt.Errorf("unknown punctuation category %q", item)
+	}
+
+	lo, hi, isRange := strings.Cut(item, "-")
+	if !isRange {
+		hi = lo
+	}
+	first, err := parseCodePoint(lo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid punctuation range %q: %v", item, err)
+	}
+	last, err := parseCodePoint(hi)
+	if err != nil {
+		return nil, fmt.Errorf("invalid punctuation range %q: %v", item, err)
+	}
+	if first > last {
+		return nil, fmt.Errorf("invalid punctuation range %q: start is after end", item)
+	}
+	return &unicode.RangeTable{R32: []unicode.Range32{{Lo: first, Hi: last, Stride: 1}}}, nil
+}
+
+// Разбор кода символа вида "U+00B7"
+func parseCodePoint(s string) (uint32, error) {
+	if !strings.HasPrefix(strings.ToUpper(s), "U+") {
+		return 0, fmt.Errorf("code point %q must start with U+", s)
+	}
+	value, err := strconv.ParseUint(s[2:], 16, 32)
+	if err != nil || value > unicode.MaxRune {
+		return 0, fmt.Errorf("invalid code point %q", s)
+	}
+	return uint32(value), nil
+}
+
+// Проверка, что все символы токена относятся к пунктуации по спецификации
+func (s *punctSet) isPunctuation(token string) bool {
+	for _, r := range token {
+		if !unicode.IsOneOf(s.include, r) || unicode.IsOneOf(s.exclude, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+// Минимальная длина токена в символах, который пытаются разбить на слова, по умолчанию
+const DefaultRunonMinLength = 12
+
+// SplitRunons разбивает слитно записанные слова ("thequickbrown"), которые часто
+// появляются при извлечении текста из PDF, на известные слова и пересчитывает частоты:
+// частота токена добавляется к каждой его части. Известные слова берутся из RunonDictFile,
+// а если он не задан — из самого словаря (токены короче RunonMinLength). Разбиваются только
+// токены не короче RunonMinLength, которых нет среди известных слов; из возможных разбиений
+// выбирается наиболее вероятное по частотам слов. Токены, которые не удается целиком
+// разбить на известные слова, сохраняются как есть.
+func (t *Tokenizer) SplitRunons(vocab map[string]int) map[string]int {
+	defer t.timeStage(timingFilter)()
+	minLength := t.opts.RunonMinLength
+	if minLength <= 0 {
+		minLength = DefaultRunonMinLength
+	}
+
+	// Известные слова и их частоты в словаре (слова из списка, которых нет в словаре, — с нулевой частотой)
+	known := make(map[string]int)
+	if t.runonWords != nil {
+		for word := range t.runonWords {
+			known[word] = vocab[word]
+		}
+	} else {
+		for token, count := range vocab {
+			if utf8.RuneCountInString(token) < minLength {
+				known[token] = count
+			}
+		}
+	}
+	total, maxLength := 0, 0
+	for word, count := range known {
+		total += count
+		if n := utf8.RuneCountInString(word); n > maxLength {
+			maxLength = n
+		}
+	}
+	logTotal := math.Log(float64(total + len(known)))
+
+	result := make(map[string]int, len(vocab))
+	split := 0
+	for token, count := range vocab {
+		if _, ok := known[token]; !ok && utf8.RuneCountInString(token) >= minLength {
+			if parts := segmentRunon(token, known, maxLength, logTotal); parts != nil {
+				for _, part := range parts {
+					result[part] += count
+				}
+				split++
+				continue
+			}
+		}
+		result[token] += count
+	}
+	fmt.Fprintf(t.out, "Split %d run-on tokens into known words\n", split)
+	return result
+}
+
+// Разбиение токена на известные слова с наибольшей суммой логарифмов вероятностей слов
+// (частоты сглажены добавлением единицы). Возвращает nil, если разбиения нет.
+func segmentRunon(token string, known map[string]int, maxLength int, logTotal float64) []string {
+	// Смещения границ символов токена
+	bounds := make([]int, 0, len(token)+1)
+	for i := range token {
+		bounds = append(bounds, i)
+	}
+	bounds = append(bounds, len(token))
+	n := len(bounds) - 1
+
+	// best[i] — оценка лучшего разбиения первых i символов, prev[i] — начало его последнего слова
+	best := make([]float64, n+1)
+	prev := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+		for j := max(0, i-maxLength); j < i; j++ {
+			if math.IsInf(best[j], -1) {
+				continue
+			}
+			count, ok := known[token[bounds[j]:bounds[i]]]
+			if !ok {
+				continue
+			}
+			if score := best[j] + math.Log(float64(count+1)) - logTotal; score > best[i] {
+				best[i], prev[i] = score, j
+			}
+		}
+	}
+	if math.IsInf(best[n], -1) {
+		return nil
+	}
+
+	var parts []string
+	for i := n; i > 0; i = prev[i] {
+		parts = append(parts, token[bounds[prev[i]]:bounds[i]])
+	}
+	slices.Reverse(parts)
+	return parts
+}
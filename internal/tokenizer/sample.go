@@ -0,0 +1,17 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Выбор файла в выборку. Решение зависит только от имени файла и Seed,
+// поэтому выборка воспроизводима и не зависит от порядка обработки.
+func (t *Tokenizer) sampled(name string) bool {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(t.opts.Seed))
+	h.Write(seed[:])
+	h.Write([]byte(name))
+	return float64(h.Sum64()>>11)/(1<<53) < t.opts.Sample
+}
@@ -0,0 +1,37 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Таблица Unicode для алфавита, заданного флагом -script
+func scriptTable(name string) (*unicode.RangeTable, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "cyrillic":
+		return unicode.Cyrillic, nil
+	case "latin":
+		return unicode.Latin, nil
+	}
+	return nil, fmt.Errorf("unsupported script %q", name)
+}
+
+// Проверка, что все буквы токена принадлежат выбранному алфавиту.
+// Остальные символы пропускаются, а в строгом режиме приводят к отбрасыванию токена.
+func (t *Tokenizer) inScript(token string) bool {
+	for _, r := range token {
+		if !unicode.IsLetter(r) {
+			if t.opts.ScriptStrict {
+				return false
+			}
+			continue
+		}
+		if !unicode.Is(t.script, r) {
+			return false
+		}
+	}
+	return true
+}
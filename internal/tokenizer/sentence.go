@@ -0,0 +1,85 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Проверка, завершает ли токен предложение: токен состоит только из знаков
+// пунктуации и содержит точку, восклицательный или вопросительный знак
+// или многоточие ("." "?!" "..." "…")
+func isSentenceEnd(token string) bool {
+	end := false
+	for _, r := range token {
+		switch {
+		case r == '.' || r == '!' || r == '?' || r == '…':
+			end = true
+		case !unicode.IsPunct(r):
+			return false
+		}
+	}
+	return end
+}
+
+// Проверка, начинает ли токен содержательную часть предложения
+// (открывающие кавычки, скобки и тире перед первым словом пропускаются)
+func isSentenceWord(token string) bool {
+	return strings.IndexFunc(token, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// Приведение первой буквы токена к нижнему регистру ("Мама" -> "мама").
+// Токены, в которых заглавные буквы встречаются и дальше ("NASA", "McDonald"),
+// вероятно являются аббревиатурами или именами и не изменяются.
+func decapitalize(token string) string {
+	first, size := utf8.DecodeRuneInString(token)
+	if !unicode.IsUpper(first) && !unicode.IsTitle(first) {
+		return token
+	}
+	if strings.IndexFunc(token[size:], unicode.IsUpper) >= 0 {
+		return token
+	}
+	return string(unicode.ToLower(first)) + token[size:]
+}
+
+// sentenceState отслеживает границы предложений при последовательной обработке токенов файла
+type sentenceState struct {
+	decap bool // Приводить первое слово предложения к нижнему регистру
+	start bool // Следующее слово начинает предложение
+	open  bool // В текущем предложении уже встретилось слово
+}
+
+func newSentenceState(decap bool) *sentenceState {
+	return &sentenceState{decap: decap, start: true}
+}
+
+// reset отмечает начало нового предложения (начало абзаца или документа).
+// Возвращает true, если при этом завершилось незаконченное предложение.
+func (s *sentenceState) reset() bool {
+	ended := s.open
+	s.start, s.open = true, false
+	return ended
+}
+
+// next обрабатывает очередной токен: первое слово предложения приводится
+// к нижнему регистру, знак конца предложения отмечает начало следующего.
+// Возвращает true, если токеном завершилось предложение.
+func (s *sentenceState) next(token string) (string, bool) {
+	if isSentenceEnd(token) {
+		return token, s.reset()
+	}
+	if !isSentenceWord(token) {
+		return token, false
+	}
+	s.open = true
+	if !s.start {
+		return token, false
+	}
+	s.start = false
+	if s.decap {
+		token = decapitalize(token)
+	}
+	return token, false
+}
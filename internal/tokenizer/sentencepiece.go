@@ -0,0 +1,53 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Служебные токены SentencePiece с идентификаторами 0, 1 и 2 (unk_id, bos_id, eos_id по умолчанию)
+var sentencePieceSpecials = []string{"<unk>", "<s>", "</s>"}
+
+// Префикс SentencePiece, обозначающий начало слова (пробел перед ним)
+const sentencePieceSpace = "▁"
+
+// Первые строки словаря SentencePiece: служебные токены с нулевой оценкой
+func sentencePieceHeader() string {
+	var b strings.Builder
+	for _, special := range sentencePieceSpecials {
+		b.WriteString(special + "\t0\n")
+	}
+	return b.String()
+}
+
+// Проверка, является ли токен служебным токеном SentencePiece
+func isSentencePieceSpecial(token string) bool {
+	for _, special := range sentencePieceSpecials {
+		if token == special {
+			return true
+		}
+	}
+	return false
+}
+
+// Построение функции форматирования строки "▁токен\tлогарифм вероятности".
+// Токен — целое слово, поэтому он получает префикс ▁, а пробелы внутри заменяются на ▁.
+// Служебные токены уже записаны в заголовке, а токены с неположительной частотой
+// не имеют логарифма вероятности; для них возвращается пустая строка.
+func sentencePieceFormatter[C countValue](vocab map[string]C) func(token string, count C) string {
+	total := 0.0
+	for _, count := range vocab {
+		if count > 0 {
+			total += float64(count)
+		}
+	}
+
+	return func(token string, count C) string {
+		if count <= 0 || isSentencePieceSpecial(token) {
+			return ""
+		}
+		piece := sentencePieceSpace + strings.ReplaceAll(token, " ", sentencePieceSpace)
+		return fmt.Sprintf("%s\t%.6f\n", piece, math.Log(float64(count)/total))
+	}
+}
@@ -0,0 +1,195 @@
+package tokenizer
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// Параметры count-min sketch по умолчанию: 5 строк по 2^20 счетчиков (40 МиБ)
+const (
+	DefaultSketchWidth = 1 << 20
+	DefaultSketchDepth = 5
+	DefaultSketchTop   = 100000
+)
+
+// Count-min sketch: depth строк по width счетчиков. Токен увеличивает по одному счетчику
+// в каждой строке, а оценка его частоты — наименьший из этих счетчиков. Оценка не бывает
+// меньше точной частоты и превышает ее не больше чем на e/width от числа всех токенов
+// с вероятностью не меньше 1 - e^(-depth). Память не зависит от размера словаря.
+type countMinSketch struct {
+	width uint64
+	depth int
+	seed  uint64   // Начальное состояние хеша с учетом Seed
+	table []uint64 // Счетчики строк подряд
+	total uint64   // Число учтенных токенов
+
+	candidates map[string]struct{} // Токены, для которых выводятся оценки (nil — самые частые)
+	top        *heavyHitters       // Самые частые токены (при candidates == nil)
+}
+
+func newCountMinSketch(width, depth int, seed int64, top int, candidates map[string]struct{}) *countMinSketch {
+	s := &countMinSketch{
+		width:      uint64(width),
+		depth:      depth,
+		seed:       seedHash(seed),
+		table:      make([]uint64, width*depth),
+		candidates: candidates,
+	}
+	if candidates == nil {
+		s.top = newHeavyHitters(top)
+	}
+	return s
+}
+
+// Номера счетчиков токена: строки используют хеши h1 + i*h2 из одного FNV-1a
+// (двойное хеширование Кирша — Митценмахера)
+func (s *countMinSketch) cells(token string, fn func(cell uint64)) {
+	sum := s.seed
+	for i := 0; i < len(token); i++ {
+		sum = (sum ^ uint64(token[i])) * fnvPrime64
+	}
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+	for i := 0; i < s.depth; i++ {
+		fn(uint64(i)*s.width + (h1+uint64(i)*h2)%s.width)
+	}
+}
+
+// Добавление словаря файла. Вызывается под блокировкой общего словаря.
+func (s *countMinSketch) add(vocab map[string]int) {
+	for token, count := range vocab {
+		estimate := uint64(math.MaxUint64)
+		s.cells(token, func(cell uint64) {
+			s.table[cell] += uint64(count)
+			estimate = min(estimate, s.table[cell])
+		})
+		s.total += uint64(count)
+		if s.top != nil {
+			s.top.offer(token, estimate)
+		}
+	}
+}
+
+// Оценка частоты токена
+func (s *countMinSketch) estimate(token string) uint64 {
+	estimate := uint64(math.MaxUint64)
+	s.cells(token, func(cell uint64) {
+		estimate = min(estimate, s.table[cell])
+	})
+	return estimate
+}
+
+// Словарь оценок: для списка токенов-кандидатов или для самых частых токенов
+func (s *countMinSketch) vocabulary() map[string]int {
+	tokens := s.candidates
+	if tokens == nil {
+		tokens = s.top.tokens()
+	}
+	vocab := make(map[string]int, len(tokens))
+	for token := range tokens {
+		if estimate := s.estimate(token); estimate > 0 {
+			vocab[token] = int(estimate)
+		}
+	}
+	return vocab
+}
+
+// Сообщение о точности оценок
+func (t *Tokenizer) printSketch() {
+	s := t.sketch
+	epsilon := math.E / float64(s.width)
+	fmt.Fprintf(t.out, "Count-min sketch %dx%d (%.1f MiB) over %d tokens: estimates may exceed true counts by up to %d with probability %.4g%%\n",
+		s.depth, s.width, float64(len(s.table)*8)/(1<<20), s.total,
+		uint64(math.Ceil(epsilon*float64(s.total))), (1-math.Exp(-float64(s.depth)))*100)
+}
+
+// Самые частые токены по оценкам sketch: не больше limit токенов в куче по возрастанию оценки.
+// Новый токен вытесняет токен с наименьшей оценкой, если его оценка больше.
+type heavyHitters struct {
+	limit   int
+	entries []heavyHitter
+	index   map[string]int // Позиции токенов в куче
+}
+
+type heavyHitter struct {
+	token    string
+	estimate uint64
+}
+
+func newHeavyHitters(limit int) *heavyHitters {
+	return &heavyHitters{limit: limit, index: make(map[string]int)}
+}
+
+func (h *heavyHitters) offer(token string, estimate uint64) {
+	if i, ok := h.index[token]; ok {
+		h.entries[i].estimate = estimate
+		heap.Fix(h, i)
+		return
+	}
+	if len(h.entries) < h.limit {
+		heap.Push(h, heavyHitter{token: token, estimate: estimate})
+		return
+	}
+	if h.limit == 0 || estimate <= h.entries[0].estimate {
+		return
+	}
+	delete(h.index, h.entries[0].token)
+	h.entries[0] = heavyHitter{token: token, estimate: estimate}
+	h.index[token] = 0
+	heap.Fix(h, 0)
+}
+
+func (h *heavyHitters) tokens() map[string]struct{} {
+	tokens := make(map[string]struct{}, len(h.entries))
+	for _, e := range h.entries {
+		tokens[e.token] = struct{}{}
+	}
+	return tokens
+}
+
+// heap.Interface
+func (h *heavyHitters) Len() int           { return len(h.entries) }
+func (h *heavyHitters) Less(i, j int) bool { return h.entries[i].estimate < h.entries[j].estimate }
+func (h *heavyHitters) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[h.entries[i].token] = i
+	h.index[h.entries[j].token] = j
+}
+func (h *heavyHitters) Push(x interface{}) {
+	e := x.(heavyHitter)
+	h.index[e.token] = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+func (h *heavyHitters) Pop() interface{} {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.index, e.token)
+	return e
+}
+
+// Создание sketch по параметрам Sketch*
+func (t *Tokenizer) initSketch() error {
+	width, depth, top := t.opts.SketchWidth, t.opts.SketchDepth, t.opts.SketchTop
+	if width == 0 {
+		width = DefaultSketchWidth
+	}
+	if depth == 0 {
+		depth = DefaultSketchDepth
+	}
+	if top == 0 {
+		top = DefaultSketchTop
+	}
+	if width < 0 || depth < 0 || top < 0 {
+		return fmt.Errorf("sketch width, depth and number of top tokens must not be negative")
+	}
+
+	var candidates map[string]struct{}
+	if t.opts.SketchTokensFile != "" {
+		var err error
+		if candidates, err = loadTokenSet(t.opts.SketchTokensFile, t.opts.Lowercase); err != nil {
+			return fmt.Errorf("failed to load sketch token list: %v", err)
+		}
+	}
+	t.sketch = newCountMinSketch(width, depth, t.opts.Seed, top, candidates)
+	return nil
+}
@@ -0,0 +1,76 @@
+package tokenizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+)
+
+// Интервал вызова OnCounts по умолчанию
+const DefaultCountsInterval = time.Second
+
+// Пора ли передать копию частот в OnCounts. Из нескольких горутин, одновременно
+// заметивших истечение интервала, копию передает только одна.
+func (t *Tokenizer) countsDue() bool {
+	if t.opts.OnCounts == nil {
+		return false
+	}
+	interval := t.opts.CountsInterval
+	if interval <= 0 {
+		interval = DefaultCountsInterval
+	}
+	now := time.Now().UnixNano()
+	next := t.nextCounts.Load()
+	return now >= next && t.nextCounts.CompareAndSwap(next, now+int64(interval))
+}
+
+// BuildReaderVocabulary создает словарь из одного потока текста. Формат потока
+// определяется по имени name, как у файлов (имя без расширения — простой текст); имя
+// используется также в сообщениях об ошибках. Поток — это обычно короткие тексты
+// (stdin, тело запроса), поэтому строки из одного слова учитываются при любом формате.
+// При OnCounts частоты передаются по ходу подсчета, не чаще CountsInterval.
+func (t *Tokenizer) BuildReaderVocabulary(r io.Reader, name string) (map[string]int, error) {
+	defer t.timeStage(timingBuild)()
+	result := &fileResult{vocab: make(map[string]int), live: t.opts.OnCounts != nil}
+	proc := t.newProcessor(name, result)
+	result.wholeLines = true
+	stopRead := t.timeStage(timingRead)
+	reader, err := proc.Process(r)
+	stopRead()
+	if err != nil {
+		return nil, fmt.Errorf("error processing %s: %v", name, err)
+	}
+	defer reader.Close()
+
+	invalidTokens, err := t.scanText(context.Background(), reader, name, result)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", name, err)
+	}
+	t.logInvalidUTF8(name, invalidTokens)
+	t.recordResult(name, result)
+
+	vocab := result.vocab
+	if t.opts.FoldCase {
+		vocab = foldCase(vocab)
+	}
+	return vocab, nil
+}
+
+// Копия словаря для OnCounts. Вызывается под блокировкой словаря, поэтому
+// копия не пересекается с подсчетом.
+func (t *Tokenizer) countsSnapshot(vocab map[string]int) map[string]int {
+	if !t.countsDue() {
+		return nil
+	}
+	return maps.Clone(vocab)
+}
+
+// Передача копии частот в OnCounts вне блокировки словаря, чтобы медленная
+// функция не задерживала подсчет в других горутинах
+func (t *Tokenizer) reportCounts(counts map[string]int) {
+	t.countsMutex.Lock()
+	defer t.countsMutex.Unlock()
+	t.opts.OnCounts(counts)
+}
@@ -0,0 +1,242 @@
+package tokenizer
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Число уникальных токенов в одной отсортированной серии по умолчанию
+const defaultMergeChunkSize = 1 << 20
+
+// StreamMergeVocabularies объединяет словари методом внешней сортировки и сразу
+// записывает результат в outputFile, не держа объединенный словарь в памяти.
+// Входные словари читаются потоково порциями по MergeChunkSize токенов; каждая порция
+// нормализуется, сортируется и сохраняется во временную серию, после чего серии
+// сливаются k-путевым слиянием. Результат отсортирован по токенам.
+func (t *Tokenizer) StreamMergeVocabularies(filePaths []string, outputFile string) error {
+	if t.opts.FoldCase || t.opts.MergeFoldCase {
+		return fmt.Errorf("case folding is not supported by streaming merge")
+	}
+	if t.opts.Format == FormatAligned {
+		return fmt.Errorf("aligned output format is not supported by streaming merge")
+	}
+	// Логарифмы вероятностей требуют общего числа токенов до записи первой строки
+	if t.opts.Format == FormatSentencePiece {
+		return fmt.Errorf("sentencepiece output format is not supported by streaming merge")
+	}
+	// Порог по процентилю зависит от всех частот, а частота UnkToken известна только
+	// после записи всех строк; MinCount применяется к каждой строке при слиянии
+	if t.opts.MinPercentile > 0 || t.opts.UnkToken != "" {
+		return fmt.Errorf("minimum percentile and unknown token are not supported by streaming merge")
+	}
+
+	chunkSize := t.opts.MergeChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultMergeChunkSize
+	}
+
+	// Серии удаляются вместе с директорией при любом исходе, в том числе при ошибке
+	tempDir, err := os.MkdirTemp(t.opts.TempDir, "vocab-merge-")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Разбиение входных словарей на отсортированные серии
+	fmt.Fprintln(t.out, "Starting to stream-merge vocabularies...")
+	t.reportVocabFormats(filePaths)
+	var runs []string
+	chunk := make(map[string]int)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		runPath := filepath.Join(tempDir, fmt.Sprintf("run-%d.txt", len(runs)))
+		if err := writeRun(chunk, runPath); err != nil {
+			return err
+		}
+		runs = append(runs, runPath)
+		chunk = make(map[string]int)
+		return nil
+	}
+
+	stopLoad := t.timeStage(timingLoad)
+	readProgress := t.newProgress("Reading and sorting", "files", len(filePaths))
+	for i, filePath := range filePaths {
+		err := t.LoadVocabularyStream(filePath, func(token string, count int) error {
+			token, ok := t.normalizeToken(token)
+			if !ok {
+				return nil
+			}
+			chunk[t.hashToken(token)] += count
+			if len(chunk) >= chunkSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error loading vocabulary from %s: %v", filePath, err)
+		}
+		readProgress.update(i+1, filePath)
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if len(filePaths) > 0 {
+		readProgress.finish()
+	}
+	stopLoad()
+
+	// Слияние серий
+	fmt.Fprintf(t.out, "Merging %d sorted runs...\n", len(runs))
+	stopSave := t.timeStage(timingSave)
+	tokens, err := t.mergeRuns(runs, outputFile)
+	stopSave()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.out, "Merging completed (%d tokens).\n", tokens)
+
+	return nil
+}
+
+// Сохранение порции словаря, отсортированной по токенам
+func writeRun(chunk map[string]int, runPath string) error {
+	tokens := make([]string, 0, len(chunk))
+	for token := range chunk {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	file, err := os.Create(runPath)
+	if err != nil {
+		return fmt.Errorf("error creating run file: %v", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, token := range tokens {
+		fmt.Fprintf(writer, "%s %d\n", token, chunk[token])
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing run file: %v", err)
+	}
+	// Ошибка записи на заполненный диск может проявиться только при закрытии
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing run file: %v", err)
+	}
+	return nil
+}
+
+// Текущая запись одной серии при слиянии
+type runEntry struct {
+	token   string
+	count   int
+	scanner *bufio.Scanner
+}
+
+// Куча записей серий, упорядоченная по токену
+type runHeap []*runEntry
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return h[i].token < h[j].token }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*runEntry)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// Чтение следующей записи серии; false, если серия закончилась
+func (e *runEntry) next() (bool, error) {
+	if !e.scanner.Scan() {
+		return false, e.scanner.Err()
+	}
+	line := e.scanner.Text()
+	i := strings.LastIndexByte(line, ' ')
+	if i < 0 {
+		return false, fmt.Errorf("invalid run entry %q", line)
+	}
+	count, err := strconv.Atoi(line[i+1:])
+	if err != nil {
+		return false, fmt.Errorf("invalid run entry %q", line)
+	}
+	e.token, e.count = line[:i], count
+	return true, nil
+}
+
+// K-путевое слияние отсортированных серий с суммированием частот одинаковых токенов.
+// Токены с суммарной частотой ниже MinCount не записываются. Возвращает число записанных токенов.
+func (t *Tokenizer) mergeRuns(runs []string, outputFile string) (int, error) {
+	h := make(runHeap, 0, len(runs))
+	for _, runPath := range runs {
+		file, err := os.Open(runPath)
+		if err != nil {
+			return 0, fmt.Errorf("error opening run file: %v", err)
+		}
+		defer file.Close()
+
+		entry := &runEntry{scanner: bufio.NewScanner(file)}
+		ok, err := entry.next()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			h = append(h, entry)
+		}
+	}
+	heap.Init(&h)
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return 0, fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	// Выровненный формат отклонен выше, остальным форматам словарь не нужен
+	formatEntry := entryFormatter[int](t, nil)
+	tokens, merged := 0, 0
+	for h.Len() > 0 {
+		token, count := h[0].token, 0
+		for h.Len() > 0 && h[0].token == token {
+			entry := h[0]
+			count += entry.count
+			ok, err := entry.next()
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				heap.Fix(&h, 0)
+			} else {
+				heap.Pop(&h)
+			}
+		}
+		merged++
+		if count < t.opts.MinCount {
+			continue
+		}
+		if err := t.writeEntry(file, formatEntry(token, count)); err != nil {
+			t.logError(fmt.Sprintf("Error writing output file %s: %v", outputFile, err))
+			return 0, fmt.Errorf("error writing file: %v", err)
+		}
+		tokens++
+	}
+	if t.opts.MinCount > 1 {
+		fmt.Fprintf(t.out, "Filtered vocabulary: %d/%d tokens kept\n", tokens, merged)
+	}
+
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return 0, err
+	}
+	return tokens, nil
+}
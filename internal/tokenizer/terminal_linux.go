@@ -0,0 +1,28 @@
+package tokenizer
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Запрос настроек терминала TCGETS завершается успешно только для tty
+func isTTY(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+// Размер окна терминала (struct winsize)
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// Число столбцов терминала по запросу TIOCGWINSZ; 0, если fd — не терминал
+func ttyColumns(fd uintptr) int {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.cols)
+}
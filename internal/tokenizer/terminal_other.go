@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package tokenizer
+
+// На других системах терминал не определяется: прогресс выводится как в файл
+func isTTY(fd uintptr) bool {
+	return false
+}
+
+func ttyColumns(fd uintptr) int {
+	return 0
+}
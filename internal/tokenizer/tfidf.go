@@ -0,0 +1,50 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SaveTFIDF сохраняет токены, упорядоченные по оценке TF-IDF (строки "токен оценка").
+// Оценка равна общей частоте токена, умноженной на log(число документов / документная частота),
+// поэтому токены, встречающиеся почти во всех документах (служебные слова), получают низкую оценку.
+// Документная частота должна быть собрана при обработке директории (DocFreq).
+func (t *Tokenizer) SaveTFIDF(vocab map[string]int, outputFile string) error {
+	fmt.Fprintln(t.out, "Saving TF-IDF scores...")
+	type TokenScore struct {
+		Token string
+		Score float64
+	}
+	documents := float64(t.documents)
+	scores := make([]TokenScore, 0, len(vocab))
+	for token, count := range vocab {
+		docFreq := t.docFreq[t.foldKey(token)]
+		if docFreq == 0 {
+			continue
+		}
+		scores = append(scores, TokenScore{Token: token, Score: float64(count) * math.Log(documents/float64(docFreq))})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Token < scores[j].Token
+	})
+
+	file, err := createOutput(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Abort()
+
+	for _, ts := range scores {
+		fmt.Fprintf(file, "%s %.4f\n", ts.Token, ts.Score)
+	}
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	return nil
+}
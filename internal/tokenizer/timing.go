@@ -0,0 +1,131 @@
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Этапы отчета о времени (Timing)
+const (
+	timingRead     = iota // Чтение файлов и извлечение текста процессорами
+	timingTokenize        // Токенизация и подсчет токенов
+	timingMerge           // Добавление словарей файлов в общий словарь
+	timingBuild           // Построение словаря из файлов целиком (по часам)
+	timingLoad            // Загрузка и объединение готовых словарей
+	timingFilter          // Фильтры готового словаря
+	timingSort            // Сортировка при сохранении
+	timingSave            // Сохранение словарей, включая сортировку
+	timingStages
+)
+
+// Накопленное время этапов. Файлы обрабатываются параллельно, поэтому время чтения,
+// токенизации и объединения суммируется по горутинам и в отчете пересчитывается
+// пропорционально времени построения словаря по часам.
+type timing struct {
+	start  time.Time
+	stages [timingStages]atomic.Int64 // Наносекунды
+}
+
+// Выключенный замер этапа
+func noTiming() {}
+
+// Начало замера этапа; возвращаемая функция завершает замер. Без Timing время не измеряется.
+func (t *Tokenizer) timeStage(stage int) func() {
+	if t.timing == nil {
+		return noTiming
+	}
+	start := time.Now()
+	return func() {
+		t.timing.stages[stage].Add(int64(time.Since(start)))
+	}
+}
+
+// Чтение с учетом времени ожидания данных: для потока процессора это время извлечения текста
+type timedReader struct {
+	reader  io.Reader
+	elapsed time.Duration
+}
+
+func (r *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.reader.Read(p)
+	r.elapsed += time.Since(start)
+	return n, err
+}
+
+// Замер чтения и токенизации текста: время в reader учитывается как чтение, остальное — как
+// токенизация. Возвращает reader для сканирования и функцию завершения замера.
+func (t *Tokenizer) timeScan(reader io.Reader) (io.Reader, func()) {
+	if t.timing == nil {
+		return reader, noTiming
+	}
+	start := time.Now()
+	timed := &timedReader{reader: reader}
+	return timed, func() {
+		t.timing.stages[timingRead].Add(int64(timed.elapsed))
+		t.timing.stages[timingTokenize].Add(int64(time.Since(start) - timed.elapsed))
+	}
+}
+
+// PrintTiming выводит время этапов с начала работы токенизатора (при Timing).
+// Сумма этапов примерно равна общему времени; неучтенное время показывается как other.
+func (t *Tokenizer) PrintTiming() {
+	if t.timing == nil {
+		return
+	}
+	stage := func(i int) time.Duration {
+		return time.Duration(t.timing.stages[i].Load())
+	}
+	total := time.Since(t.timing.start)
+
+	// Время горутин обработки файлов делится между чтением, токенизацией и объединением
+	// в той же пропорции, в какой оно потрачено во всех горутинах вместе
+	build := stage(timingBuild)
+	worker := stage(timingRead) + stage(timingTokenize) + stage(timingMerge)
+	share := func(i int) time.Duration {
+		if worker == 0 {
+			return 0
+		}
+		return time.Duration(float64(build) * float64(stage(i)) / float64(worker))
+	}
+
+	rows := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"read and extract", share(timingRead)},
+		{"tokenize", share(timingTokenize)},
+		{"merge", share(timingMerge)},
+		{"load vocabularies", stage(timingLoad)},
+		{"filter", stage(timingFilter)},
+		{"sort", stage(timingSort)},
+		{"write", stage(timingSave) - stage(timingSort)},
+	}
+	// Короткие запуски показываются с точностью до микросекунд
+	precision := time.Millisecond
+	if total < time.Second {
+		precision = time.Microsecond
+	}
+	row := func(name string, duration time.Duration) {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(duration) / float64(total) * 100
+		}
+		fmt.Fprintf(t.out, "  %-18s %10s %6.1f%%\n", name, duration.Round(precision), percent)
+	}
+
+	other := total
+	fmt.Fprintln(t.out, "Timing:")
+	for _, r := range rows {
+		other -= r.duration
+		row(r.name, r.duration)
+	}
+	row("other", max(other, 0))
+	fmt.Fprintf(t.out, "  %-18s %10s\n", "total", total.Round(precision))
+	if build > 0 {
+		fmt.Fprintf(t.out, "  (files were processed in %s of worker time over %s of wall-clock time)\n",
+			worker.Round(precision), build.Round(precision))
+	}
+}
@@ -2,187 +2,646 @@ package tokenizer
 
 import (
 	"bufio"
-	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
-	"github.com/terratensor/segment"
+	"github.com/terratensor/vocab/internal/processor"
+	"github.com/terratensor/vocab/internal/stemmer"
 )
 
+// Options задает параметры токенизатора
+type Options struct {
+	Lowercase       bool              // Приводить токены к нижнему регистру
+	FilterPunct     bool              // Отбрасывать токены из знаков препинания
+	TrimPunct       bool              // Обрезать знаки препинания в начале и конце токенов
+	PunctCategories string            // Категории Unicode и диапазоны символов пунктуации для FilterPunct (пусто — P и S)
+	Processor       processor.Options // Параметры извлечения текста из файлов
+
+	ProgressInterval time.Duration // Минимальный интервал между выводами прогресса (0 — по умолчанию для терминала или файла)
+
+	WhitelistFile  string // Файл со списком токенов, которые нужно оставить в словаре
+	BlacklistFile  string // Файл со списком токенов, которые нужно удалить из словаря
+	DictionaryFile string // Файл со списком слов: при подсчете учитываются только токены из него
+
+	SplitRunons    bool   // Разбивать слитно записанные слова на известные слова (SplitRunons)
+	RunonMinLength int    // Минимальная длина разбиваемого токена в символах (0 — DefaultRunonMinLength)
+	RunonDictFile  string // Файл с известными словами для разбиения (пусто — слова самого словаря)
+
+	Lemmatize     bool   // Приводить токены к лемме перед подсчетом
+	LemmaDictFile string // Словарь лемм: строки "словоформа лемма"
+
+	Stem     bool   // Приводить токены к основе стеммером Snowball
+	StemLang string // Язык стеммера: russian или english
+
+	PosDictFile string // Словарь частей речи: строки "словоформа ТЕГ"
+	PosFilter   string // Теги частей речи через запятую: учитываются только токены с ними (пусто — все)
+	PosTags     bool   // Добавлять к токенам тег части речи ("дом_NOUN")
+
+	SplitHyphens    bool // Разбивать слова через дефис на части
+	KeepApostrophes bool // Склеивать слова, разделенные апострофом
+	KeepEmoji       bool // Выделять эмодзи (включая ZWJ-последовательности) в отдельные токены и не отбрасывать их как пунктуацию
+
+	NumToken             string // Токен, которым заменяются числа перед подсчетом (пусто — числа не заменяются)
+	DropDigitTokens      bool   // Отбрасывать токены, содержащие хотя бы одну цифру
+	DropUnderscoreTokens bool   // Отбрасывать токены, содержащие подчеркивание
+
+	FoldCase bool // Объединять токены, отличающиеся регистром, под самым частым написанием
+
+	Format         string // Формат вывода словаря: text, aligned или tokens
+	ThousandsSep   bool   // Разделять разряды частот в формате aligned
+	HumanCounts    bool   // Сокращать частоты от тысячи (1.2M, 3.4B) в формате aligned и статистике
+	OutputEncoding string // Кодировка выходного словаря: utf-8 (по умолчанию), windows-1251 или iso-8859-1
+	Unmappable     string // Обработка символов, которых нет в OutputEncoding: replace, skip или error
+
+	LangConfidence float64 // Минимальная доля служебных слов определенного языка файла при разделении по языкам
+
+	Script       string // Алфавит, которому должны принадлежать буквы токенов: cyrillic или latin
+	ScriptStrict bool   // Отбрасывать токены с символами, кроме букв, при фильтрации по алфавиту
+
+	Provenance      bool // Запоминать файлы, в которых встретился каждый токен
+	ProvenanceLimit int  // Максимальное число файлов, запоминаемых для одного токена
+	ProvenanceAbs   bool // Запоминать абсолютные пути файлов
+
+	MergeChunkSize int       // Число уникальных токенов в одной серии потокового объединения
+	TempDir        string    // Директория для временных файлов потокового объединения (пусто — системная)
+	Duplicates     string    // Обработка повторяющихся токенов при загрузке словаря: sum, last, first или error
+	InputOrder     string    // Порядок полей во входных словарях: token-count или count-token
+	MergeWeights   []float64 // Веса объединяемых словарей в порядке файлов (nil — все веса равны 1)
+	MergeFoldCase  bool      // Объединять при слиянии словарей токены, отличающиеся регистром, как FoldCase
+	CountPrecision int       // Число знаков после запятой при записи дробных частот (0 — 6 знаков)
+
+	FileTimeout    time.Duration // Максимальное время обработки одного файла (0 — без ограничения)
+	MaxGoroutines  int           // Число горутин для обработки загруженного словаря (0 или 1 — последовательно)
+	WorkersPerFile int           // Число частей, на которые делится большой текстовый файл для параллельной обработки
+	FetchTimeout   time.Duration // Максимальное время загрузки и обработки одного документа по URL (0 — без ограничения)
+	FetchRetries   int           // Число повторных попыток загрузки по URL при временных ошибках
+	HTTPClient     *http.Client  // Клиент для загрузки по URL (nil — http.DefaultClient), например с подписью запросов S3
+
+	ErrorDir  string // Папка для лога ошибок и копий проблемных файлов (по умолчанию vocab_errors)
+	LogFile   string // Лог ошибок (по умолчанию vocab_errors.log в папке ошибок)
+	ErrorMode string // Обработка файлов с ошибками: copy (по умолчанию), list или none
+	Dedup     bool   // Пропускать файлы с уже встречавшимся содержимым
+
+	ExcludeGlobs []string // Шаблоны filepath.Match: совпадающие файлы директории не обрабатываются
+
+	DocFreq       bool    // Считать документную частоту токенов
+	DocDelimiter  string  // Строка-разделитель документов внутри файла ("blank" — пустая строка); без него документ — весь файл
+	MinDocFreq    int     // Минимальная документная частота токена (требует DocFreq)
+	MinCount      int     // Минимальная частота токена
+	MinPercentile float64 // Доля (в процентах) самых редких токенов, отбрасываемых по частоте (0 — без отбора)
+	UnkToken      string  // Токен, в который суммируются частоты токенов ниже MinCount (пусто — отбрасывать)
+
+	Index      bool // Запоминать позиции токенов (файл, строка, смещение)
+	IndexLimit int  // Максимальное число позиций, запоминаемых для одного токена
+
+	Sample float64 // Доля обрабатываемых файлов (0 или 1 — все файлы)
+	Seed   int64   // Начальное значение для всех случайных выборов (выборка файлов, примеры)
+
+	// Новые файлы не обрабатываются, как только в обработанных файлах подсчитано
+	// столько токенов; начатые файлы дообрабатываются (0 — без ограничения)
+	MaxTokens int64
+
+	// Наибольшее число записей общего словаря при построении: при превышении редкие токены
+	// вытесняются, и частоты становятся приблизительными (0 — без ограничения)
+	MaxVocabSize int
+
+	Sketch           bool   // Считать токены в count-min sketch: частоты приблизительны, память фиксирована
+	SketchWidth      int    // Число счетчиков в строке sketch (0 — DefaultSketchWidth)
+	SketchDepth      int    // Число строк sketch (0 — DefaultSketchDepth)
+	SketchTop        int    // Число самых частых токенов, оценки которых выводятся (0 — DefaultSketchTop)
+	SketchTokensFile string // Файл со списком токенов, оценки которых выводятся вместо самых частых
+
+	Examples int // Число примеров строк, сохраняемых для каждого токена
+
+	HashBuckets int  // Число корзин хеширования токенов: вместо токенов учитываются номера корзин (0 — без хеширования)
+	HashTokens  bool // Запоминать представителя каждой корзины для SaveHashTokens
+
+	NormalizeWhitespace bool   // Удалять пробельные и невидимые символы внутри и по краям токенов
+	InvalidUTF8         string // Обработка токенов с некорректным UTF-8: keep, drop или strip
+
+	ByteLevel bool // Считать частоты отдельных байтов текста ("<0x41>") вместо токенов
+
+	SplitIdentifiers bool // Разбивать идентификаторы (CamelCase, snake_case) на слова
+	SplitDigits      bool // Выделять цифры в отдельные части при разбиении идентификаторов
+
+	DecapSentenceStart bool   // Приводить к нижнему регистру первое слово каждого предложения
+	EOSToken           string // Токен конца предложения, добавляемый после каждого предложения (пусто — не добавлять)
+
+	Quiet  bool // Не выводить прогресс и информационные сообщения
+	Stderr bool // Выводить прогресс и информационные сообщения в stderr (когда словарь пишется в stdout)
+
+	// Вывод предупреждений, итоговой сводки ошибок и лога ошибок, если лог-файл создать
+	// не удалось (nil — os.Stderr). Не зависит от Quiet.
+	Diagnostics io.Writer
+	Timing      bool // Измерять время этапов для отчета PrintTiming
+
+	// Вызывается во время построения словаря с копией текущих частот не чаще
+	// CountsInterval: после добавления файла в общий словарь (BuildVocabulary,
+	// BuildURLVocabulary) и по ходу чтения потока (BuildReaderVocabulary).
+	// Копия принадлежит вызываемой функции; вызовы не происходят одновременно.
+	OnCounts       func(counts map[string]int)
+	CountsInterval time.Duration // Минимальный интервал между вызовами OnCounts (0 — DefaultCountsInterval)
+
+	FirstSeen bool // Запоминать порядок первого появления токенов документа для сортировки SortFirstSeen
+}
+
 type Tokenizer struct {
-	lowercase   bool
-	filterPunct bool
-	errorDir    string
-	logFile     *os.File
+	opts     Options
+	errorDir string    // Папка для копий проблемных файлов (пусто — файлы не копируются)
+	logFile  *os.File  // Лог-файл ошибок (nil, если он не создан)
+	logOut   io.Writer // Лог ошибок: logFile, diag, если лог-файл создать не удалось, или nil в режиме none
+	terminal bool      // Выводится ли прогресс в терминал
+	out      io.Writer // Вывод прогресса и информационных сообщений
+	diag     io.Writer // Вывод предупреждений и сводки ошибок
+
+	whitelist  map[string]struct{}
+	blacklist  map[string]struct{}
+	dictionary map[string]struct{} // Слова, которые учитываются при подсчете (nil — все токены)
+	runonWords map[string]struct{} // Известные слова для SplitRunons (nil — слова словаря)
+	lemmas     map[string]string
+	posTags    map[string]string   // Части речи словоформ (nil — без определения части речи)
+	posAllowed map[string]struct{} // Теги PosFilter (nil — все)
+	stem       stemmer.Stemmer
+	script     *unicode.RangeTable
+	punct      *punctSet     // Символы пунктуации (nil — знаки препинания и символы)
+	charset    *charset      // Кодировка выходного словаря (nil — UTF-8)
+	buckets    *hashBuckets  // Корзины хеширования токенов (nil — без хеширования)
+	filters    []TokenFilter // Цепочка преобразований токенов
+
+	sources      map[string]*tokenSources // Файлы-источники токенов
+	sourcesMutex sync.Mutex
+
+	contents     map[string]string // Хеши содержимого обработанных файлов (для -dedup)
+	contentMutex sync.Mutex
+
+	docFreq   map[string]int // Документная частота токенов
+	documents int            // Число обработанных документов
+	docMutex  sync.Mutex
+
+	postings   map[string]*tokenPostings // Позиции токенов
+	indexMutex sync.Mutex
+
+	examples      map[string]*exampleReservoir // Примеры строк с токенами
+	examplesMutex sync.Mutex
+
+	failures      []FileFailure // Файлы, которые не удалось обработать
+	failuresMutex sync.Mutex
+
+	codeVocab map[string]int // Словарь кода (при Processor.Code == CodeSeparate)
+	codeMutex sync.Mutex
+
+	metrics *metrics // Метрики обработки (WriteMetrics)
+	timing  *timing  // Время этапов (nil — без Timing)
+
+	tokensRead atomic.Int64 // Токены обработанных файлов (для MaxTokens)
+
+	pruned     pruneStats // Вытеснение редких токенов (для MaxVocabSize)
+	pruneMutex sync.Mutex
+
+	sketch *countMinSketch // Приблизительный подсчет (nil — точный)
+
+	firstSeen map[string]int // Порядок первого появления токенов документа (для SortFirstSeen)
+
+	nextCounts  atomic.Int64 // Время следующего вызова OnCounts (Unix, наносекунды)
+	countsMutex sync.Mutex   // Вызовы OnCounts по очереди
 }
 
-func NewTokenizer(lowercase, filterPunct bool) (*Tokenizer, error) {
-	// Создаем папку для ошибок
-	errorDir := "vocab_errors"
-	if err := os.MkdirAll(errorDir, os.ModePerm); err != nil {
-		return nil, fmt.Errorf("failed to create error directory: %v", err)
+func NewTokenizer(opts Options) (*Tokenizer, error) {
+	if !validFormat(opts.Format) {
+		return nil, fmt.Errorf("unknown output format %q", opts.Format)
 	}
-
-	// Создаем лог-файл
-	logFile, err := os.OpenFile(filepath.Join(errorDir, "vocab_errors.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if !validInvalidUTF8Mode(opts.InvalidUTF8) {
+		return nil, fmt.Errorf("unknown invalid UTF-8 mode %q", opts.InvalidUTF8)
+	}
+	if !validDuplicatePolicy(opts.Duplicates) {
+		return nil, fmt.Errorf("unknown duplicate token policy %q", opts.Duplicates)
+	}
+	if !validInputOrder(opts.InputOrder) {
+		return nil, fmt.Errorf("unknown input field order %q", opts.InputOrder)
+	}
+	if !validErrorMode(opts.ErrorMode) {
+		return nil, fmt.Errorf("unknown error mode %q", opts.ErrorMode)
+	}
+	charset, err := lookupCharset(opts.OutputEncoding)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create log file: %v", err)
+		return nil, err
+	}
+	if !validUnmappableMode(opts.Unmappable) {
+		return nil, fmt.Errorf("unknown unmappable character mode %q", opts.Unmappable)
+	}
+	if err := validExcludeGlobs(opts.ExcludeGlobs); err != nil {
+		return nil, err
+	}
+	if opts.HashBuckets < 0 {
+		return nil, fmt.Errorf("number of hash buckets must not be negative")
+	}
+
+	diag := opts.Diagnostics
+	if diag == nil {
+		diag = os.Stderr
+	}
+
+	// Создаем папку для ошибок и лог-файл. Если это невозможно (например, текущая
+	// директория доступна только для чтения), проблемные файлы не копируются,
+	// а ошибки выводятся в Diagnostics. В режиме none ни папка, ни лог не создаются
+	var errorDir string
+	var logFile *os.File
+	var logOut io.Writer
+	if opts.ErrorMode != ErrorModeNone {
+		errorDir = opts.ErrorDir
+		if errorDir == "" {
+			errorDir = DefaultErrorDir
+		}
+		logFile, err = openErrorLog(errorDir, opts.LogFile)
+		if err != nil {
+			fmt.Fprintf(diag, "Warning: %v; failing files will not be copied, errors are printed here instead\n", err)
+			errorDir, logOut = "", diag
+		} else {
+			logOut = logFile
+		}
+		if opts.ErrorMode == ErrorModeList {
+			errorDir = ""
+		}
+	}
+
+	t := &Tokenizer{
+		opts:     opts,
+		errorDir: errorDir,
+		logFile:  logFile,
+		logOut:   logOut,
+		terminal: isTerminal(os.Stdout),
+		out:      os.Stdout,
+		diag:     diag,
+		charset:  charset,
+		metrics:  newMetrics(),
+	}
+	if opts.Stderr {
+		t.terminal = isTerminal(os.Stderr)
+		t.out = os.Stderr
+	}
+	if opts.Quiet {
+		t.out = io.Discard
+	}
+	if opts.HashBuckets > 0 {
+		t.buckets = newHashBuckets(opts.HashBuckets, opts.Seed, opts.HashTokens)
+	}
+	if opts.Timing {
+		t.timing = &timing{start: time.Now()}
+	}
+	if opts.Sketch {
+		if err := t.initSketch(); err != nil {
+			t.Close()
+			return nil, err
+		}
+	}
+	if t.opts.Processor.Log == nil {
+		t.opts.Processor.Log = t.logError
+	}
+
+	// Загружаем белый и черный списки токенов
+	if opts.WhitelistFile != "" {
+		if t.whitelist, err = loadTokenSet(opts.WhitelistFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load whitelist: %v", err)
+		}
+	}
+	if opts.BlacklistFile != "" {
+		if t.blacklist, err = loadTokenSet(opts.BlacklistFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load blacklist: %v", err)
+		}
+	}
+	if opts.RunonDictFile != "" {
+		if t.runonWords, err = loadTokenSet(opts.RunonDictFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load run-on word list: %v", err)
+		}
+	}
+	if opts.DictionaryFile != "" {
+		if t.dictionary, err = loadTokenSet(opts.DictionaryFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load dictionary: %v", err)
+		}
+	}
+
+	// Загружаем словарь лемм
+	if opts.Lemmatize {
+		if opts.LemmaDictFile == "" {
+			t.Close()
+			return nil, fmt.Errorf("lemmatization requires a lemma dictionary")
+		}
+		if t.lemmas, err = loadLemmaDict(opts.LemmaDictFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load lemma dictionary: %v", err)
+		}
+	}
+
+	// Загружаем словарь частей речи
+	if opts.PosFilter != "" || opts.PosTags {
+		if opts.PosDictFile == "" {
+			t.Close()
+			return nil, fmt.Errorf("part-of-speech tagging requires a part-of-speech dictionary")
+		}
+		if t.posTags, err = loadPosDict(opts.PosDictFile, opts.Lowercase); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to load part-of-speech dictionary: %v", err)
+		}
+		t.posAllowed = parsePosFilter(opts.PosFilter)
+	}
+
+	// Выбираем алфавит для фильтрации токенов
+	if t.script, err = scriptTable(opts.Script); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	// Разбираем категории пунктуации
+	if t.punct, err = parsePunctCategories(opts.PunctCategories); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	// Выбираем стеммер
+	if opts.Stem {
+		if t.stem, err = stemmer.New(opts.StemLang); err != nil {
+			t.Close()
+			return nil, err
+		}
 	}
 
-	return &Tokenizer{
-		lowercase:   lowercase,
-		filterPunct: filterPunct,
-		errorDir:    errorDir,
-		logFile:     logFile,
-	}, nil
+	t.filters = t.builtinFilters()
+
+	return t, nil
 }
 
 func (t *Tokenizer) Close() {
-	t.logFile.Close()
+	if t.logFile != nil {
+		t.logFile.Close()
+	}
 }
 
-// Загрузка словаря из файла
+// Загрузка словаря из файла. Повторяющиеся токены обрабатываются согласно политике Duplicates
+// (по умолчанию частоты суммируются).
 func (t *Tokenizer) LoadVocabulary(filePath string) (map[string]int, error) {
-	vocab := make(map[string]int)
+	defer t.timeStage(timingLoad)()
+	return loadVocabulary(t, filePath, strconv.Atoi)
+}
 
-	file, err := os.Open(filePath)
+// Загрузка словаря с дробными частотами (целые частоты тоже допускаются)
+func (t *Tokenizer) LoadFloatVocabulary(filePath string) (map[string]float64, error) {
+	defer t.timeStage(timingLoad)()
+	return loadVocabulary(t, filePath, parseFloatCount)
+}
+
+func loadVocabulary[C countValue](t *Tokenizer, filePath string, parse func(string) (C, error)) (map[string]C, error) {
+	vocab := make(map[string]C)
+	err := loadVocabularyStream(t, filePath, parse, func(token string, count C) error {
+		return addLoadedEntry(t, vocab, token, count)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error opening vocabulary file: %v", err)
+		return nil, err
+	}
+	return vocab, nil
+}
+
+// LoadVocabularyStream читает словарь построчно и вызывает fn для каждой записи,
+// не загружая словарь в память целиком. Формат файла (текст, CSV, TSV или JSON)
+// определяется DetectVocabularyFormat. Ошибка fn прерывает чтение и возвращается как есть.
+func (t *Tokenizer) LoadVocabularyStream(filePath string, fn func(token string, count int) error) error {
+	return loadVocabularyStream(t, filePath, strconv.Atoi, fn)
+}
+
+func loadVocabularyStream[C countValue](t *Tokenizer, filePath string, parse func(string) (C, error), fn func(token string, count C) error) error {
+	format, err := DetectVocabularyFormat(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening vocabulary file: %v", err)
+	}
+	file, err := openVocabFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening vocabulary file: %v", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, " ")
-		if len(parts) != 2 {
-			continue // Пропускаем некорректные строки
+	invalidCounts := 0
+	var fnErr error
+	err = t.readVocabEntries(file, format, func(lineNumber int, token, countText string) error {
+		count, err := parse(countText)
+		if err != nil {
+			// Строки с некорректной частотой пропускаются, а не учитываются с нулевой частотой
+			invalidCounts++
+			t.logError(fmt.Sprintf("Invalid count %q on line %d of %s", countText, lineNumber, filePath))
+			return nil
 		}
-		token := parts[0]
-		count := 0
-		fmt.Sscanf(parts[1], "%d", &count)
-		vocab[token] = count
+		fnErr = fn(token, count)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading vocabulary file: %v", err)
+	if err != nil {
+		return fmt.Errorf("error reading %s vocabulary file: %v", format, err)
+	}
+	if invalidCounts > 0 {
+		fmt.Fprintf(t.diag, "Warning: skipped %d lines with invalid counts in %s%s\n", invalidCounts, filePath, t.logHint())
 	}
 
-	return vocab, nil
+	return nil
 }
 
 // Объединение словарей из нескольких файлов
 func (t *Tokenizer) MergeVocabularies(filePaths []string) (map[string]int, error) {
-	mergedVocab := make(map[string]int)
+	if t.opts.MergeWeights == nil {
+		return mergeVocabularies(t, filePaths, strconv.Atoi)
+	}
+
+	// С весами частоты суммируются дробными и округляются после суммирования
+	weighted, err := t.MergeFloatVocabularies(filePaths)
+	if err != nil {
+		return nil, err
+	}
+	mergedVocab := make(map[string]int, len(weighted))
+	for token, count := range weighted {
+		// Токены, частота которых округлилась до нуля, отбрасываются
+		if rounded := int(math.Round(count)); rounded != 0 {
+			mergedVocab[token] = rounded
+		}
+	}
+	return mergedVocab, nil
+}
+
+// Объединение словарей с дробными частотами. Частоты каждого файла умножаются
+// на его вес из MergeWeights, если веса заданы.
+func (t *Tokenizer) MergeFloatVocabularies(filePaths []string) (map[string]float64, error) {
+	return mergeVocabularies(t, filePaths, parseFloatCount)
+}
+
+func mergeVocabularies[C countValue](t *Tokenizer, filePaths []string, parse func(string) (C, error)) (map[string]C, error) {
+	defer t.timeStage(timingLoad)()
+	if t.opts.MergeWeights != nil && len(t.opts.MergeWeights) != len(filePaths) {
+		return nil, fmt.Errorf("got %d merge weights for %d files", len(t.opts.MergeWeights), len(filePaths))
+	}
+	mergedVocab := make(map[string]C)
 
-	fmt.Println("Starting to merge vocabularies...")
-	totalFiles := len(filePaths)
+	fmt.Fprintln(t.out, "Starting to merge vocabularies...")
+	t.reportVocabFormats(filePaths)
+	mergeProgress := t.newProgress("Merging", "files", len(filePaths))
 
 	for i, filePath := range filePaths {
-		fmt.Printf("\rReading and merging file %d/%d: %s", i+1, totalFiles, filePath)
-		vocab, err := t.LoadVocabulary(filePath)
+		weight := 1.0
+		if t.opts.MergeWeights != nil {
+			weight = t.opts.MergeWeights[i]
+		}
+		// Объединяем словари, не загружая каждый файл в память целиком
+		err := loadVocabularyStream(t, filePath, parse, func(token string, count C) error {
+			if weight != 1 {
+				count = C(float64(count) * weight)
+			}
+			mergedVocab[token] += count
+			return nil
+		})
 		if err != nil {
 			return nil, fmt.Errorf("error loading vocabulary from %s: %v", filePath, err)
 		}
+		mergeProgress.update(i+1, filePath)
+	}
+	mergeProgress.finish()
 
-		// Объединяем словари
-		for token, count := range vocab {
-			mergedVocab[token] += count
-		}
+	// Объединение вариантов написания из словарей, построенных с разными настройками регистра
+	if t.opts.MergeFoldCase {
+		mergedVocab = foldCase(mergedVocab)
 	}
-	fmt.Println("\nMerging completed.")
+	fmt.Fprintln(t.out, "Merging completed.")
 
 	return mergedVocab, nil
 }
 
-// Обработка словаря (приведение к нижнему регистру, фильтрация пунктуации)
+// Обработка словаря (приведение к нижнему регистру, фильтрация пунктуации, белый и черный списки)
 func (t *Tokenizer) ProcessVocabulary(vocab map[string]int) map[string]int {
-	fmt.Println("Processing vocabulary...")
-	processedVocab := make(map[string]int)
-	totalTokens := len(vocab)
-	processedTokens := 0
-	progressStep := totalTokens / 100 // Шаг для вывода прогресса (1%)
+	defer t.timeStage(timingFilter)()
+	return processVocabulary(t, vocab)
+}
 
-	if progressStep == 0 {
-		progressStep = 1 // Минимальный шаг
-	}
+// Обработка словаря с дробными частотами
+func (t *Tokenizer) ProcessFloatVocabulary(vocab map[string]float64) map[string]float64 {
+	return processVocabulary(t, vocab)
+}
 
-	for token, count := range vocab {
-		// Приведение к нижнему регистру
-		if t.lowercase {
-			token = strings.ToLower(token)
-		}
+func processVocabulary[C countValue](t *Tokenizer, vocab map[string]C) map[string]C {
+	fmt.Fprintln(t.out, "Processing vocabulary...")
+	tokenProgress := t.newProgress("Processing", "tokens", len(vocab))
 
-		// Фильтрация пунктуации
-		if t.filterPunct && isPunctuation(token) {
-			continue
-		}
+	var processedVocab map[string]C
+	invalidTokens := 0
+	if workers := t.vocabWorkers(len(vocab)); workers > 1 {
+		processedVocab, invalidTokens = processVocabularyParallel(t, vocab, workers, tokenProgress)
+	} else {
+		processedVocab = make(map[string]C)
+		for token, count := range vocab {
+			tokenProgress.add(1)
 
-		// Обновление словаря
-		processedVocab[token] += count
-		processedTokens++
+			// Обработка некорректного UTF-8, приведение к нижнему регистру, фильтрация
+			// пунктуации, лемматизация, белый и черный списки
+			token, ok, invalid := t.processEntry(token)
+			if invalid {
+				invalidTokens++
+			}
+			if !ok {
+				continue
+			}
 
-		// Вывод прогресса с шагом
-		if processedTokens%progressStep == 0 {
-			fmt.Printf("\rProcessed %d/%d tokens (%d%%)", processedTokens, totalTokens, processedTokens*100/totalTokens)
+			// Обновление словаря
+			processedVocab[token] += count
 		}
 	}
 
 	// Финальный вывод прогресса
-	fmt.Printf("\rProcessed %d/%d tokens (100%%)\n", totalTokens, totalTokens)
-	fmt.Println("Processing completed.")
+	tokenProgress.finish()
+	t.logInvalidUTF8("vocabulary", invalidTokens)
+
+	// Объединение вариантов написания
+	if t.opts.FoldCase {
+		processedVocab = foldCase(processedVocab)
+	}
+	fmt.Fprintln(t.out, "Processing completed.")
 
 	return processedVocab
 }
 
 // Сохранение словаря в файл с учетом сортировки
 func (t *Tokenizer) SaveVocabulary(vocab map[string]int, outputFile string, sortType string) error {
-	fmt.Println("Saving vocabulary...")
-	file, err := os.Create(outputFile)
+	return saveVocabulary(t, vocab, outputFile, sortType)
+}
+
+// Сохранение словаря с дробными частотами; частоты записываются с точностью CountPrecision
+func (t *Tokenizer) SaveFloatVocabulary(vocab map[string]float64, outputFile string, sortType string) error {
+	return saveVocabulary(t, vocab, outputFile, sortType)
+}
+
+func saveVocabulary[C countValue](t *Tokenizer, vocab map[string]C, outputFile string, sortType string) error {
+	fmt.Fprintln(t.out, "Saving vocabulary...")
+	defer t.metrics.observe(StageSave, time.Now())
+	defer t.timeStage(timingSave)()
+	// Запись во временный файл, который заменяет outputFile только после успешного сохранения,
+	// или в stdout, если outputFile равен "-"
+	file, err := createOutput(outputFile)
 	if err != nil {
 		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
 		return fmt.Errorf("error creating file: %v", err)
 	}
-	defer file.Close()
+	defer file.Abort()
+
+	// SentencePiece ожидает служебные токены в начале словаря, а остальные — по убыванию оценки
+	if t.opts.Format == FormatSentencePiece {
+		sortType = "freq"
+		if err := t.writeEntry(file, sentencePieceHeader()); err != nil {
+			t.logError(fmt.Sprintf("Error writing output file %s: %v", outputFile, err))
+			return fmt.Errorf("error writing file: %v", err)
+		}
+	}
+
+	formatEntry := entryFormatter(t, vocab)
 
 	// Если сортировка не требуется, сохраняем словарь как есть
 	if sortType == "" {
-		totalTokens := len(vocab)
-		savedTokens := 0
-		progressStep := totalTokens / 100 // Шаг для вывода прогресса (1%)
-
-		if progressStep == 0 {
-			progressStep = 1 // Минимальный шаг
-		}
+		tokenProgress := t.newProgress("Saving", "tokens", len(vocab))
 
 		for token, count := range vocab {
-			file.WriteString(fmt.Sprintf("%s %d\n", token, count))
-			savedTokens++
-
-			// Вывод прогресса с шагом
-			if savedTokens%progressStep == 0 {
-				fmt.Printf("\rSaved %d/%d tokens (%d%%)", savedTokens, totalTokens, savedTokens*100/totalTokens)
+			if err := t.writeEntry(file, formatEntry(token, count)); err != nil {
+				t.logError(fmt.Sprintf("Error writing output file %s: %v", outputFile, err))
+				return fmt.Errorf("error writing file: %v", err)
 			}
+			tokenProgress.add(1)
 		}
 
 		// Финальный вывод прогресса
-		fmt.Printf("\rSaved %d/%d tokens (100%%)\n", totalTokens, totalTokens)
-		fmt.Println("Saving completed.")
+		tokenProgress.finish()
+		if err := file.Commit(); err != nil {
+			t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+			return err
+		}
+		fmt.Fprintln(t.out, "Saving completed.")
 		return nil
 	}
 
 	// Преобразуем словарь в слайс для сортировки
 	type TokenFrequency struct {
 		Token string
-		Count int
+		Count C
 	}
 	var tokenFrequencies []TokenFrequency
 	for token, count := range vocab {
@@ -190,150 +649,478 @@ func (t *Tokenizer) SaveVocabulary(vocab map[string]int, outputFile string, sort
 	}
 
 	// Сортировка
-	fmt.Println("Sorting vocabulary...")
+	fmt.Fprintln(t.out, "Sorting vocabulary...")
 	startTime := time.Now()
+	stopSort := t.timeStage(timingSort)
 	switch sortType {
 	case "freq":
+		// Токены с равной частотой упорядочиваются по алфавиту, чтобы вывод не зависел
+		// от порядка обхода словаря
 		sort.Slice(tokenFrequencies, func(i, j int) bool {
-			return tokenFrequencies[i].Count > tokenFrequencies[j].Count
+			if tokenFrequencies[i].Count != tokenFrequencies[j].Count {
+				return tokenFrequencies[i].Count > tokenFrequencies[j].Count
+			}
+			return tokenFrequencies[i].Token < tokenFrequencies[j].Token
 		})
 	case "alpha":
 		sort.Slice(tokenFrequencies, func(i, j int) bool {
 			return tokenFrequencies[i].Token < tokenFrequencies[j].Token
 		})
+	case SortFirstSeen:
+		sort.Slice(tokenFrequencies, func(i, j int) bool {
+			return t.firstSeenLess(tokenFrequencies[i].Token, tokenFrequencies[j].Token)
+		})
 	}
-	fmt.Printf("Sorting completed in %v.\n", time.Since(startTime))
+	stopSort()
+	fmt.Fprintf(t.out, "Sorting completed in %v.\n", time.Since(startTime))
 
 	// Записываем отсортированные данные в файл
-	totalTokens := len(tokenFrequencies)
-	savedTokens := 0
-	progressStep := totalTokens / 100 // Шаг для вывода прогресса (1%)
-
-	if progressStep == 0 {
-		progressStep = 1 // Минимальный шаг
-	}
+	tokenProgress := t.newProgress("Saving", "tokens", len(tokenFrequencies))
 
 	for _, tf := range tokenFrequencies {
-		file.WriteString(fmt.Sprintf("%s %d\n", tf.Token, tf.Count))
-		savedTokens++
-
-		// Вывод прогресса с шагом
-		if savedTokens%progressStep == 0 {
-			fmt.Printf("\rSaved %d/%d tokens (%d%%)", savedTokens, totalTokens, savedTokens*100/totalTokens)
+		if err := t.writeEntry(file, formatEntry(tf.Token, tf.Count)); err != nil {
+			t.logError(fmt.Sprintf("Error writing output file %s: %v", outputFile, err))
+			return fmt.Errorf("error writing file: %v", err)
 		}
+		tokenProgress.add(1)
 	}
 
 	// Финальный вывод прогресса
-	fmt.Printf("\rSaved %d/%d tokens (100%%)\n", totalTokens, totalTokens)
-	fmt.Println("Saving completed.")
+	tokenProgress.finish()
+	if err := file.Commit(); err != nil {
+		t.logError(fmt.Sprintf("Error saving output file %s: %v", outputFile, err))
+		return err
+	}
+	fmt.Fprintln(t.out, "Saving completed.")
 
 	return nil
 }
 
 // Обработка файлов и создание словаря
 func (t *Tokenizer) ProcessFiles(dirPath string, maxGoroutines int, outputFile string, sortType string) error {
-	var vocab = make(map[string]int)
+	vocab, err := t.BuildVocabulary(dirPath, maxGoroutines)
+	if err != nil {
+		return err
+	}
+
+	// Сохранение словаря
+	return t.SaveVocabulary(vocab, outputFile, sortType)
+}
+
+// Создание словаря из файлов в директории
+func (t *Tokenizer) BuildVocabulary(dirPath string, maxGoroutines int) (map[string]int, error) {
+	vocabs, err := t.buildVocabularies(dirPath, maxGoroutines, nil)
+	if err != nil {
+		return nil, err
+	}
+	return vocabs[""], nil
+}
+
+// Создание словаря из одного документа любого поддерживаемого формата (текст, DOCX, CSV и т.д.)
+func (t *Tokenizer) BuildFileVocabulary(filePath string) (map[string]int, error) {
+	result, ok := t.processFileWithTimeout(filePath)
+	if !ok {
+		failures := t.Failures()
+		return nil, errors.New(failures[len(failures)-1].Message)
+	}
+	t.recordResult(filePath, result)
+	if t.opts.FirstSeen {
+		t.firstSeen = result.order
+	}
+
+	vocab := result.vocab
+	if t.opts.FoldCase {
+		vocab = foldCase(vocab)
+	}
+	return vocab, nil
+}
+
+// Учет документной частоты, позиций, примеров и источников токенов обработанного файла
+func (t *Tokenizer) recordResult(filePath string, result *fileResult) {
+	if t.opts.DocFreq {
+		t.recordDocFreq(result)
+	}
+	if t.opts.Index {
+		t.recordPostings(result)
+	}
+	if t.opts.Examples > 0 {
+		t.recordExamples(result)
+	}
+
+	if t.opts.Provenance {
+		t.recordProvenance(filePath, result.vocab)
+	}
+	if result.code != nil {
+		t.recordCode(result)
+	}
+	t.countTokensRead(result)
+}
+
+// Число записей директории, читаемых за один раз
+const dirBatchSize = 1024
+
+// Построение словарей из файлов директории с распределением файлов по группам.
+// Функция group определяет группу файла по его словарю; если она не задана,
+// все файлы попадают в группу "".
+func (t *Tokenizer) buildVocabularies(dirPath string, maxGoroutines int, group func(localVocab map[string]int) string) (map[string]map[string]int, error) {
+	defer t.metrics.observe(StageBuild, time.Now())
+	defer t.timeStage(timingBuild)()
+	var vocabs = map[string]map[string]int{"": {}}
 	var mutex sync.Mutex
 	guard := make(chan struct{}, maxGoroutines)
 	var wg sync.WaitGroup
 
-	files, err := os.ReadDir(dirPath)
+	// Директория читается частями по dirBatchSize записей, и обработка файлов начинается
+	// до окончания чтения: список огромной директории не хранится в памяти целиком.
+	// Общее число файлов известно только после чтения всей директории, поэтому до тех пор
+	// прогресс показывает число уже найденных файлов.
+	dir, err := os.Open(dirPath)
 	if err != nil {
-		return fmt.Errorf("error reading directory %s: %v", dirPath, err)
+		return nil, fmt.Errorf("error reading directory %s: %v", dirPath, err)
 	}
+	defer dir.Close()
 
-	totalFiles := len(files)
-	processedFiles := 0
-	var progressMutex sync.Mutex
+	// Обработка только случайной доли файлов для быстрой оценки
+	sampling := t.opts.Sample > 0 && t.opts.Sample < 1
 
-	for _, fileEntry := range files {
-		if fileEntry.IsDir() {
-			continue
-		}
+	totalFiles, listedFiles := 0, 0
+	duplicateFiles, excludedFiles := 0, 0
+	truncated := false
+	fileProgress := t.newProgress("Processing", "files", 0)
+	var duplicateMutex sync.Mutex
+
+listing:
+	for {
+		batch, err := dir.ReadDir(dirBatchSize)
+		for _, fileEntry := range batch {
+			if fileEntry.IsDir() {
+				continue
+			}
+			if pattern, ok := t.excludedBy(dirPath, fileEntry.Name()); ok {
+				t.logError(fmt.Sprintf("Skipped excluded file %s (matches %s)", filepath.Join(dirPath, fileEntry.Name()), pattern))
+				excludedFiles++
+				continue
+			}
+			listedFiles++
+			if sampling && !t.sampled(fileEntry.Name()) {
+				continue
+			}
 
-		wg.Add(1)
-		go func(fileEntry os.DirEntry) {
-			defer wg.Done()
+			// Горутина запускается только при свободном месте, поэтому число ожидающих
+			// горутин не растет с размером директории
 			guard <- struct{}{}
-			defer func() { <-guard }()
+			// Предел проверяется после ожидания: за это время завершаются начатые файлы
+			if t.tokenLimitReached() {
+				<-guard
+				truncated = true
+				break listing
+			}
+			totalFiles++
+			fileProgress.grow(1)
+			wg.Add(1)
+			go func(fileEntry os.DirEntry) {
+				defer wg.Done()
+				defer func() { <-guard }()
+				// Обработанным считается и пропущенный или завершившийся ошибкой файл
+				defer fileProgress.add(1)
 
-			filePath := filepath.Join(dirPath, fileEntry.Name())
-			var reader io.Reader
+				filePath := filepath.Join(dirPath, fileEntry.Name())
 
-			// Открываем файл
-			file, err := os.Open(filePath)
-			if err != nil {
-				t.logError(fmt.Sprintf("Error opening file %s: %v", filePath, err))
-				t.copyErrorFile(filePath)
-				return
-			}
-			defer file.Close()
-
-			// Если файл в формате .gz, распаковываем его
-			if strings.HasSuffix(fileEntry.Name(), ".gz") {
-				gzReader, err := gzip.NewReader(file)
-				if err != nil {
-					t.logError(fmt.Sprintf("Error decompressing file %s: %v", filePath, err))
-					t.copyErrorFile(filePath)
+				// Пропуск дубликатов
+				if t.opts.Dedup {
+					if first, ok := t.duplicateOf(filePath); ok {
+						t.logError(fmt.Sprintf("Skipped duplicate file %s (same content as %s)", filePath, first))
+						duplicateMutex.Lock()
+						duplicateFiles++
+						duplicateMutex.Unlock()
+						return
+					}
+				}
+
+				// Обработка файла
+				result, ok := t.processFileWithTimeout(filePath)
+				if !ok {
 					return
 				}
-				defer gzReader.Close()
-				reader = gzReader
-			} else {
-				reader = file
-			}
+				localVocab := result.vocab
+				t.recordResult(filePath, result)
+
+				key := ""
+				if group != nil {
+					key = group(localVocab)
+				}
 
-			// Обработка файла
-			localVocab := make(map[string]int)
-			scanner := bufio.NewScanner(reader)
-			for scanner.Scan() {
-				line := scanner.Text()
-				tokens := segment.NewTokenizer().Tokenize(line)
-				for _, token := range tokens {
-					tokenText := token.Text
-					if t.lowercase {
-						tokenText = strings.ToLower(tokenText)
+				stopMerge := t.timeStage(timingMerge)
+				var snapshot map[string]int
+				mutex.Lock()
+				if t.sketch != nil {
+					t.sketch.add(localVocab)
+				} else {
+					vocab, ok := vocabs[key]
+					if !ok {
+						vocab = make(map[string]int)
+						vocabs[key] = vocab
 					}
-					if t.filterPunct && isPunctuation(tokenText) {
-						continue
+					for token, count := range localVocab {
+						vocab[token] += count
+					}
+					t.pruneVocabulary(vocab)
+					if group == nil {
+						snapshot = t.countsSnapshot(vocab)
 					}
-					localVocab[tokenText]++
 				}
-			}
+				mutex.Unlock()
+				stopMerge()
+				if snapshot != nil {
+					t.reportCounts(snapshot)
+				}
+			}(fileEntry)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			wg.Wait()
+			return nil, fmt.Errorf("error reading directory %s: %v", dirPath, err)
+		}
+	}
+	fileProgress.complete()
 
-			if err := scanner.Err(); err != nil {
-				t.logError(fmt.Sprintf("Error reading file %s: %v", filePath, err))
-				t.copyErrorFile(filePath)
-				return
-			}
+	wg.Wait()
+	if totalFiles > 0 {
+		fileProgress.finish()
+	}
+	if sampling {
+		fmt.Fprintf(t.out, "Sampled %d/%d files (%.0f%%); the vocabulary is an estimate\n", totalFiles, listedFiles, t.opts.Sample*100)
+	}
+	if truncated {
+		t.printTruncated(totalFiles)
+	}
+	t.printPruned()
+	t.printFailureSummary(totalFiles)
+	if duplicateFiles > 0 {
+		fmt.Fprintf(t.out, "Skipped %d duplicate files\n", duplicateFiles)
+	}
+	if excludedFiles > 0 {
+		fmt.Fprintf(t.out, "Skipped %d files matching -exclude-glob\n", excludedFiles)
+	}
 
-			mutex.Lock()
-			for token, count := range localVocab {
-				vocab[token] += count
-			}
-			mutex.Unlock()
+	// Оценки частот из sketch
+	if t.sketch != nil {
+		vocabs[""] = t.sketch.vocabulary()
+		t.printSketch()
+	}
 
-			progressMutex.Lock()
-			processedFiles++
-			fmt.Printf("\rProgress: %d/%d files processed (%.2f%%)", processedFiles, totalFiles, float64(processedFiles)/float64(totalFiles)*100)
-			progressMutex.Unlock()
-		}(fileEntry)
+	// Объединение вариантов написания
+	if t.opts.FoldCase {
+		for key, vocab := range vocabs {
+			vocabs[key] = foldCase(vocab)
+		}
 	}
 
-	wg.Wait()
-	fmt.Println()
+	return vocabs, nil
+}
 
-	// Сохранение словаря
-	return t.SaveVocabulary(vocab, outputFile, sortType)
+// Обработка файла с ограничением времени FileTimeout.
+// По истечении времени файл записывается в лог и копируется в папку ошибок, а его
+// обработка прерывается; результат незавершенной обработки отбрасывается.
+func (t *Tokenizer) processFileWithTimeout(filePath string) (*fileResult, bool) {
+	defer t.metrics.observe(StageFile, time.Now())
+	if t.opts.FileTimeout <= 0 {
+		return t.processFile(context.Background(), filePath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.FileTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result *fileResult
+		ok     bool
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, ok := t.processFile(ctx, filePath)
+		done <- outcome{result, ok}
+	}()
+
+	select {
+	case r := <-done:
+		return r.result, r.ok
+	case <-ctx.Done():
+		t.fileFailed(filePath, FailureTimeout, fmt.Sprintf("Timeout processing file %s after %v", filePath, t.opts.FileTimeout))
+		return nil, false
+	}
+}
+
+// Результат обработки одного файла
+type fileResult struct {
+	vocab     map[string]int // Частоты токенов
+	docFreq   map[string]int // Число документов файла с каждым токеном (при DocFreq)
+	documents int            // Число документов в файле (при DocFreq)
+
+	postings map[string]*tokenPostings    // Позиции токенов (при Index)
+	examples map[string]*exampleReservoir // Примеры строк (при Examples)
+
+	code map[string]int // Частоты токенов кода (при отдельном словаре кода)
+
+	order map[string]int // Порядковые номера первого появления токенов (при FirstSeen)
+
+	live bool // Передавать частоты в OnCounts по ходу подсчета (поток BuildReaderVocabulary)
+
+	// Учитывать строки из одного слова, которые segment не разбивает: ячейки таблиц,
+	// значения полей, абзацы DOCX ("Итого"). Задается процессором (processor.WholeLines).
+	wholeLines bool
+}
+
+// Построение словаря одного файла. При ошибке файл учитывается согласно ErrorMode
+// (по умолчанию записывается в лог и копируется в папку ошибок). После отмены ctx файл закрывается, чтобы прервать чтение.
+func (t *Tokenizer) processFile(ctx context.Context, filePath string) (*fileResult, bool) {
+	fail := func(kind, format string, args ...interface{}) (*fileResult, bool) {
+		// Об отмене по таймауту сообщает вызывающая функция
+		if ctx.Err() == nil {
+			t.fileFailed(filePath, kind, fmt.Sprintf(format, args...))
+		}
+		return nil, false
+	}
+
+	// Открываем файл
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fail(FailureOpen, "Error opening file %s: %v", filePath, err)
+	}
+	defer file.Close()
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	// Учет обработанного файла в метриках (файл, прерванный по таймауту, не учитывается)
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	done := func(result *fileResult) (*fileResult, bool) {
+		if ctx.Err() == nil {
+			t.metrics.fileProcessed(result, size)
+		}
+		return result, true
+	}
+
+	// Большой текстовый файл обрабатывается частями параллельно
+	result := &fileResult{vocab: make(map[string]int)}
+	proc := t.newProcessor(filePath, result)
+	if bounds := t.fileChunks(file, proc); bounds != nil {
+		result, invalidTokens, err := t.processChunks(ctx, file, filePath, bounds)
+		if err != nil {
+			return fail(FailureRead, "Error reading file %s: %v", filePath, err)
+		}
+		t.logInvalidUTF8(filePath, invalidTokens)
+		return done(result)
+	}
+
+	// Извлекаем текст процессором, подходящим для формата файла
+	stopRead := t.timeStage(timingRead)
+	reader, err := proc.Process(file)
+	stopRead()
+	if err != nil {
+		return fail(FailureFormat, "Error processing file %s: %v", filePath, err)
+	}
+	defer reader.Close()
+
+	invalidTokens, err := t.scanText(ctx, reader, filePath, result)
+	if err != nil {
+		return fail(FailureRead, "Error reading file %s: %v", filePath, err)
+	}
+	t.logInvalidUTF8(filePath, invalidTokens)
+
+	return done(result)
+}
+
+// Токенизация текста файла с подсчетом частот в result (при ByteLevel — подсчет байтов).
+// Возвращает число токенов с некорректным UTF-8; после отмены ctx возвращает ошибку ctx.
+func (t *Tokenizer) scanText(ctx context.Context, reader io.Reader, filePath string, result *fileResult) (int, error) {
+	reader, stopScan := t.timeScan(reader)
+	defer stopScan()
+	if t.opts.ByteLevel {
+		return 0, t.scanBytes(ctx, reader, result)
+	}
+
+	doc := t.newDocCounter(result)
+	sentence := newSentenceState(t.opts.DecapSentenceStart)
+
+	var line string
+	var offsets []int
+	lineNumber, invalidTokens := 0, 0
+	emit := func(token string, start int) {
+		result.vocab[token]++
+		if t.opts.FirstSeen {
+			recordFirstSeen(result, token)
+		}
+		doc.add(token)
+		if start < 0 {
+			return // Маркер конца предложения
+		}
+		if offsets != nil {
+			t.addPosting(result, token, posting{file: filePath, line: lineNumber, offset: offsets[start]})
+		}
+		if t.opts.Examples > 0 {
+			t.addExample(result, token, filePath, lineNumber, line)
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		line = scanner.Text()
+		lineNumber++
+		// Граница документа внутри файла
+		if t.isDocDelimiter(line) {
+			t.endSentence(sentence.reset(), emit)
+			doc.end()
+			continue
+		}
+
+		// Пустая строка разделяет абзацы: предложение без завершающего знака заканчивается
+		if strings.TrimSpace(line) == "" {
+			t.endSentence(sentence.reset(), emit)
+		}
+
+		if t.opts.Index {
+			offsets = runeOffsets(line)
+		}
+		invalidTokens += t.lineTokens(line, result.wholeLines, sentence, emit)
+		if result.live && t.countsDue() {
+			t.reportCounts(maps.Clone(result.vocab))
+		}
+	}
+	t.endSentence(sentence.reset(), emit)
+	doc.end()
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return invalidTokens, ctx.Err()
 }
 
 // Логирование ошибок
 func (t *Tokenizer) logError(message string) {
-	log.New(t.logFile, "", log.LstdFlags).Println(message)
+	if t.logOut == nil {
+		return
+	}
+	log.New(t.logOut, "", log.LstdFlags).Println(message)
 }
 
 // Копирование проблемных файлов
 func (t *Tokenizer) copyErrorFile(filePath string) {
+	// Документы, загруженные по URL, не сохраняются
+	if t.errorDir == "" || isURL(filePath) {
+		return
+	}
+	// Каналы и устройства не копируем: чтение из них может не завершиться
+	if info, err := os.Stat(filePath); err == nil && !info.Mode().IsRegular() {
+		return
+	}
+
 	srcFile, err := os.Open(filePath)
 	if err != nil {
 		t.logError(fmt.Sprintf("Error opening error file %s: %v", filePath, err))
@@ -355,6 +1142,16 @@ func (t *Tokenizer) copyErrorFile(filePath string) {
 	}
 }
 
+// Проверка, состоит ли токен только из букв
+func isWord(token string) bool {
+	for _, r := range token {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return token != ""
+}
+
 // Проверка, является ли токен знаком препинания
 func isPunctuation(token string) bool {
 	for _, r := range token {
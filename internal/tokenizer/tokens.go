@@ -0,0 +1,182 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/terratensor/segment"
+	seg "github.com/terratensor/segment/segment"
+)
+
+// Токенизация строки с постобработкой токенов (эмодзи, дефисы, апострофы, идентификаторы).
+// Позиции сегментов (Start, End) отсчитываются в рунах от начала строки.
+// Строка из одного атома не дает разбиений, и segment возвращает пустой список;
+// при wholeLine такая строка учитывается как один токен.
+func (t *Tokenizer) tokenizeLine(line string, wholeLine bool) []seg.Segment {
+	segments := segment.NewTokenizer().Tokenize(line)
+	if len(segments) == 0 && wholeLine {
+		if text := strings.TrimSpace(line); text != "" {
+			start := utf8.RuneCountInString(line[:strings.Index(line, text)])
+			segments = []seg.Segment{{Text: text, Start: start, End: start + utf8.RuneCountInString(text)}}
+		}
+	}
+	if t.opts.KeepEmoji {
+		tokens := make([]seg.Segment, 0, len(segments))
+		for _, s := range joinEmojiParts(segments) {
+			tokens = append(tokens, splitEmoji(s)...)
+		}
+		segments = tokens
+	}
+	if t.opts.KeepApostrophes {
+		segments = joinApostrophes(segments)
+	}
+	if t.opts.SplitHyphens {
+		tokens := make([]seg.Segment, 0, len(segments))
+		for _, s := range segments {
+			tokens = append(tokens, splitHyphens(s)...)
+		}
+		segments = tokens
+	}
+	if t.opts.SplitIdentifiers {
+		tokens := make([]seg.Segment, 0, len(segments))
+		for _, s := range segments {
+			tokens = append(tokens, splitIdentifier(s, t.opts.SplitDigits)...)
+		}
+		segments = tokens
+	}
+	return segments
+}
+
+// Обработка токенов строки: проверка UTF-8, границы предложений и цепочка фильтров.
+// emit вызывается для каждого учитываемого токена с позицией его начала в рунах;
+// маркер конца предложения передается с позицией -1. Возвращает число токенов
+// с некорректным UTF-8. wholeLine — учитывать строку из одного атома (см. tokenizeLine).
+func (t *Tokenizer) lineTokens(line string, wholeLine bool, sentence *sentenceState, emit func(token string, start int)) int {
+	invalidTokens := 0
+	for _, token := range t.tokenizeLine(line, wholeLine) {
+		tokenText, ok, invalid := t.checkUTF8(token.Text)
+		if invalid {
+			invalidTokens++
+		}
+		if !ok {
+			continue
+		}
+		tokenText, ended := sentence.next(tokenText)
+		if tokenText, ok = t.normalizeToken(tokenText); ok {
+			emit(t.hashToken(tokenText), token.Start)
+		}
+		t.endSentence(ended, emit)
+	}
+	return invalidTokens
+}
+
+// Маркер конца предложения учитывается как обычный токен, но не проходит фильтры
+func (t *Tokenizer) endSentence(ended bool, emit func(token string, start int)) {
+	if ended && t.opts.EOSToken != "" {
+		emit(t.hashToken(t.opts.EOSToken), -1)
+	}
+}
+
+// TokenizeText разбивает текст на токены с теми же параметрами, что и при обработке файлов
+// (фильтры, границы предложений, маркер конца предложения), в порядке следования.
+// Строки из одного слова учитываются. При ByteLevel возвращает токены байтов текста.
+// Безопасна для вызова из нескольких горутин.
+func (t *Tokenizer) TokenizeText(text string) []string {
+	var tokens []string
+	if t.opts.ByteLevel {
+		for i := 0; i < len(text); i++ {
+			tokens = append(tokens, byteToken(text[i]))
+		}
+		return tokens
+	}
+
+	sentence := newSentenceState(t.opts.DecapSentenceStart)
+	emit := func(token string, start int) {
+		tokens = append(tokens, token)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		// Пустая строка разделяет абзацы
+		if strings.TrimSpace(line) == "" {
+			t.endSentence(sentence.reset(), emit)
+		}
+		t.lineTokens(line, true, sentence, emit)
+	}
+	t.endSentence(sentence.reset(), emit)
+	return tokens
+}
+
+// Проверка, является ли руна апострофом
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’' || r == 'ʼ'
+}
+
+// Склейка слов, разделенных апострофом без пробелов (don't, rock'n'roll, д’Артаньян)
+func joinApostrophes(segments []seg.Segment) []seg.Segment {
+	joined := make([]seg.Segment, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		current := segments[i]
+		for i+2 < len(segments) {
+			apostrophe, next := segments[i+1], segments[i+2]
+			runes := []rune(apostrophe.Text)
+			if len(runes) != 1 || !isApostrophe(runes[0]) ||
+				current.End != apostrophe.Start || apostrophe.End != next.Start ||
+				!endsWithLetter(current.Text) || !startsWithLetter(next.Text) {
+				break
+			}
+			current = seg.Segment{
+				Text:  current.Text + apostrophe.Text + next.Text,
+				Start: current.Start,
+				End:   next.End,
+			}
+			i += 2
+		}
+		joined = append(joined, current)
+	}
+	return joined
+}
+
+func startsWithLetter(s string) bool {
+	for _, r := range s {
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+func endsWithLetter(s string) bool {
+	runes := []rune(s)
+	return len(runes) > 0 && unicode.IsLetter(runes[len(runes)-1])
+}
+
+// Разбиение токена по дефисам ("из-за" -> "из", "за") с сохранением позиций частей.
+// Токены, состоящие только из дефисов, не изменяются.
+func splitHyphens(token seg.Segment) []seg.Segment {
+	var parts []seg.Segment
+	runes := []rune(token.Text)
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !isHyphen(runes[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			parts = append(parts, seg.Segment{
+				Text:  string(runes[start:i]),
+				Start: token.Start + start,
+				End:   token.Start + i,
+			})
+			start = -1
+		}
+	}
+	if len(parts) == 0 {
+		return []seg.Segment{token}
+	}
+	return parts
+}
+
+// Проверка, является ли руна дефисом
+func isHyphen(r rune) bool {
+	return r == '-' || r == '‐' || r == '‑'
+}
@@ -0,0 +1,156 @@
+package tokenizer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func countToken(tokens []string, token string) int {
+	n := 0
+	for _, t := range tokens {
+		if t == token {
+			n++
+		}
+	}
+	return n
+}
+
+// Семья 👨‍👩‍👧 (три эмодзи, соединенные ZWJ) — один токен
+func TestTokenizeTextKeepEmojiFamily(t *testing.T) {
+	const family = "👨‍👩‍👧"
+	tok := newTestTokenizer(t, Options{KeepEmoji: true, FilterPunct: true})
+	text := "Привет " + family + " мир " + family + "!"
+	tokens := tok.TokenizeText(text)
+	if countToken(tokens, family) != 2 {
+		t.Errorf("TokenizeText = %q, want %q twice", tokens, family)
+	}
+	for _, part := range []string{"👨", "👩", "👧", "‍"} {
+		if slices.Contains(tokens, part) {
+			t.Errorf("TokenizeText = %q, family split into %q", tokens, part)
+		}
+	}
+
+	vocab, err := tok.BuildReaderVocabulary(strings.NewReader(text), "text.txt")
+	if err != nil {
+		t.Fatalf("BuildReaderVocabulary: %v", err)
+	}
+	if vocab[family] != 2 {
+		t.Errorf("vocab[%q] = %d, want 2 (vocabulary: %v)", family, vocab[family], vocab)
+	}
+}
+
+// Пробелы нулевой ширины и неразрывные пробелы внутри и по краям токена удаляются,
+// и такие токены учитываются вместе с чистыми
+func TestTokenizeTextNormalizeWhitespace(t *testing.T) {
+	tok := newTestTokenizer(t, Options{NormalizeWhitespace: true})
+	tokens := tok.TokenizeText("слово \u200bслово сло\u200bво \ufeffслово слово\u200b. сло\u2060во мир\u00a0мир")
+	if n := countToken(tokens, "слово"); n != 6 {
+		t.Errorf("TokenizeText = %q, want 6 tokens %q", tokens, "слово")
+	}
+	if n := countToken(tokens, "мир"); n != 2 || len(tokens) != 9 {
+		t.Errorf("TokenizeText = %q, want 2 tokens %q and no whitespace tokens", tokens, "мир")
+	}
+
+	// В готовых словарях неразрывный пробел может оказаться внутри токена
+	vocab := tok.ProcessVocabulary(map[string]int{"слово": 1, "сло\u00a0во": 2, "\u200bслово\u00a0": 3, "\u00a0": 4})
+	if vocab["слово"] != 6 || len(vocab) != 1 {
+		t.Errorf("ProcessVocabulary = %v, want map[слово:6]", vocab)
+	}
+}
+
+// Маркер конца предложения учитывается один раз на предложение: после знаков конца
+// предложения и в конце абзаца без точки. Перенос строки внутри абзаца предложение не завершает.
+func TestTokenizeTextEOSToken(t *testing.T) {
+	const eos = "</s>"
+	tok := newTestTokenizer(t, Options{EOSToken: eos, FilterPunct: true})
+	text := "Кот спит. Пес лает! Кто там?.. Тишина\n\nНовый абзац\nпродолжается на второй строке."
+	tokens := tok.TokenizeText(text)
+	if n := countToken(tokens, eos); n != 5 {
+		t.Errorf("TokenizeText = %q, want %d tokens %q", tokens, 5, eos)
+	}
+	if tokens[len(tokens)-1] != eos {
+		t.Errorf("TokenizeText = %q, want %q at the end", tokens, eos)
+	}
+
+	vocab, err := tok.BuildReaderVocabulary(strings.NewReader(text), "text.txt")
+	if err != nil {
+		t.Fatalf("BuildReaderVocabulary: %v", err)
+	}
+	if vocab[eos] != 5 {
+		t.Errorf("vocab[%q] = %d, want 5", eos, vocab[eos])
+	}
+}
+
+// Все числа заменяются одним токеном, и их частоты суммируются
+func TestTokenizeTextNumToken(t *testing.T) {
+	const num = "<NUM>"
+	tok := newTestTokenizer(t, Options{NumToken: num, FilterPunct: true})
+	text := "В 2019 и 2020 годах вышло 3 книги."
+	tokens := tok.TokenizeText(text)
+	want := []string{"В", num, "и", num, "годах", "вышло", num, "книги"}
+	if !slices.Equal(tokens, want) {
+		t.Errorf("TokenizeText = %q, want %q", tokens, want)
+	}
+
+	vocab, err := tok.BuildReaderVocabulary(strings.NewReader(text), "text.txt")
+	if err != nil {
+		t.Fatalf("BuildReaderVocabulary: %v", err)
+	}
+	for _, number := range []string{"2019", "2020", "3"} {
+		if _, ok := vocab[number]; ok {
+			t.Errorf("vocab contains %q, want it replaced by %q", number, num)
+		}
+	}
+	if vocab[num] != 3 {
+		t.Errorf("vocab[%q] = %d, want 3", num, vocab[num])
+	}
+}
+
+// Пунктуация по краям токена обрезается: токены в скобках и перед запятой
+// учитываются вместе с чистыми, а токены только из пунктуации отбрасываются
+func TestTokenizeTextTrimPunct(t *testing.T) {
+	tok := newTestTokenizer(t, Options{TrimPunct: true})
+	for _, token := range []string{"(слово)", "слово,", "«слово»,", "слово"} {
+		if got, ok := tok.normalizeToken(token); !ok || got != "слово" {
+			t.Errorf("normalizeToken(%q) = %q, %v, want %q", token, got, ok, "слово")
+		}
+	}
+	if got, ok := tok.normalizeToken("),"); ok {
+		t.Errorf("normalizeToken(%q) = %q, want the token dropped", "),", got)
+	}
+
+	tokens := tok.TokenizeText("Одно (слово) и слово, и еще слово.")
+	if n := countToken(tokens, "слово"); n != 3 {
+		t.Errorf("TokenizeText = %q, want 3 tokens %q", tokens, "слово")
+	}
+	for _, token := range tokens {
+		if strings.ContainsAny(token, "(),.") {
+			t.Errorf("TokenizeText = %q, token %q keeps punctuation", tokens, token)
+		}
+	}
+}
+
+// Первое слово предложения приводится к нижнему регистру, имена собственные внутри
+// предложения и аббревиатуры сохраняют заглавные буквы. Новое предложение начинается
+// после "?!", многоточия и "…", открывающие кавычки перед первым словом пропускаются
+func TestTokenizeTextDecapSentenceStart(t *testing.T) {
+	tok := newTestTokenizer(t, Options{DecapSentenceStart: true, FilterPunct: true})
+	text := "Мы видели Москву. NASA запустило ракету?! Она улетела… Потом пришел Иван... «Так» и было"
+	tokens := tok.TokenizeText(text)
+	want := []string{"мы", "видели", "Москву", "NASA", "запустило", "ракету", "она", "улетела",
+		"потом", "пришел", "Иван", "так", "и", "было"}
+	if !slices.Equal(tokens, want) {
+		t.Errorf("TokenizeText = %q, want %q", tokens, want)
+	}
+
+	vocab, err := tok.BuildReaderVocabulary(strings.NewReader(text), "text.txt")
+	if err != nil {
+		t.Fatalf("BuildReaderVocabulary: %v", err)
+	}
+	for _, token := range want {
+		if vocab[token] != 1 {
+			t.Errorf("vocab[%q] = %d, want 1 (vocabulary: %v)", token, vocab[token], vocab)
+		}
+	}
+}
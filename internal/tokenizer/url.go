@@ -0,0 +1,238 @@
+package tokenizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Параметры загрузки по URL по умолчанию
+const (
+	DefaultFetchTimeout = time.Minute
+	DefaultFetchRetries = 3
+)
+
+// Пауза перед первой повторной попыткой загрузки; каждая следующая пауза вдвое длиннее
+const fetchRetryDelay = time.Second
+
+// Расширения, по которым процессор выбирается без учета Content-Type
+var knownExtensions = map[string]bool{
+	".txt": true, ".csv": true, ".tsv": true, ".jsonl": true, ".ndjson": true, ".docx": true,
+	".md": true, ".markdown": true, ".pdf": true,
+}
+
+// Расширения для типов содержимого, если URL не оканчивается известным расширением
+var contentTypeExtensions = map[string]string{
+	"text/plain":                "",
+	"text/csv":                  ".csv",
+	"text/tab-separated-values": ".tsv",
+	"text/markdown":             ".md",
+	"application/x-ndjson":      ".jsonl",
+	"application/jsonl":         ".jsonl",
+	"application/pdf":           ".pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/gzip":   ".gz",
+	"application/x-gzip": ".gz",
+}
+
+// Проверка, является ли путь адресом http(s)
+func isURL(filePath string) bool {
+	return strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://")
+}
+
+// Ошибка загрузки документа; transient — повторная попытка может быть успешной
+type fetchError struct {
+	kind      string
+	message   string
+	transient bool
+}
+
+// BuildURLVocabulary создает словарь из документов, загружаемых по адресам http(s).
+// Тело ответа читается потоково и передается процессору, выбранному по расширению в пути
+// URL или, если его нет, по Content-Type. Сжатие gzip при передаче (Content-Encoding)
+// снимается прозрачно. Загрузка ограничена FetchTimeout; при сетевых ошибках, ответах 5xx
+// и 429 она повторяется до FetchRetries раз. Документы обрабатываются параллельно,
+// не более maxGoroutines одновременно.
+func (t *Tokenizer) BuildURLVocabulary(urls []string, maxGoroutines int) (map[string]int, error) {
+	defer t.metrics.observe(StageBuild, time.Now())
+	defer t.timeStage(timingBuild)()
+	vocab := make(map[string]int)
+	var mutex sync.Mutex
+	guard := make(chan struct{}, maxGoroutines)
+	var wg sync.WaitGroup
+
+	urlProgress := t.newProgress("Fetching", "urls", len(urls))
+	fetched := 0
+	for _, rawURL := range urls {
+		guard <- struct{}{}
+		if t.tokenLimitReached() {
+			<-guard
+			break
+		}
+		fetched++
+		wg.Add(1)
+		go func(rawURL string) {
+			defer wg.Done()
+			defer func() { <-guard }()
+			defer urlProgress.add(1)
+
+			result, ok := t.processURL(rawURL)
+			if !ok {
+				return
+			}
+			t.recordResult(rawURL, result)
+
+			stopMerge := t.timeStage(timingMerge)
+			var snapshot map[string]int
+			mutex.Lock()
+			if t.sketch != nil {
+				t.sketch.add(result.vocab)
+			} else {
+				for token, count := range result.vocab {
+					vocab[token] += count
+				}
+				t.pruneVocabulary(vocab)
+				snapshot = t.countsSnapshot(vocab)
+			}
+			mutex.Unlock()
+			stopMerge()
+			if snapshot != nil {
+				t.reportCounts(snapshot)
+			}
+		}(rawURL)
+	}
+
+	wg.Wait()
+	if len(urls) > 0 {
+		urlProgress.finish()
+	}
+	if fetched < len(urls) {
+		t.printTruncated(fetched)
+	}
+	t.printPruned()
+	t.printFailureSummary(fetched)
+
+	if t.sketch != nil {
+		vocab = t.sketch.vocabulary()
+		t.printSketch()
+	}
+	if t.opts.FoldCase {
+		vocab = foldCase(vocab)
+	}
+	return vocab, nil
+}
+
+// Загрузка и обработка документа с повторными попытками при временных ошибках
+func (t *Tokenizer) processURL(rawURL string) (*fileResult, bool) {
+	start := time.Now()
+	defer t.metrics.observe(StageFile, start)
+
+	delay := fetchRetryDelay
+	for attempt := 0; ; attempt++ {
+		result, size, err := t.fetchURL(rawURL)
+		if err == nil {
+			t.metrics.fileProcessed(result, size)
+			return result, true
+		}
+		if !err.transient || attempt >= t.opts.FetchRetries {
+			t.fileFailed(rawURL, err.kind, err.message)
+			return nil, false
+		}
+		t.logError(fmt.Sprintf("%s; retrying in %v", err.message, delay))
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Одна попытка загрузки документа. Возвращает результат и число прочитанных байтов.
+func (t *Tokenizer) fetchURL(rawURL string) (*fileResult, int64, *fetchError) {
+	ctx := context.Background()
+	if t.opts.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opts.FetchTimeout)
+		defer cancel()
+	}
+	fail := func(kind string, transient bool, format string, args ...interface{}) (*fileResult, int64, *fetchError) {
+		if ctx.Err() != nil {
+			kind, transient = FailureTimeout, true
+			format, args = "Timeout fetching %s after %v", []interface{}{rawURL, t.opts.FetchTimeout}
+		}
+		return nil, 0, &fetchError{kind: kind, message: fmt.Sprintf(format, args...), transient: transient}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fail(FailureOpen, false, "Error fetching %s: %v", rawURL, err)
+	}
+	client := t.opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(FailureOpen, true, "Error fetching %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		transient := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return fail(FailureOpen, transient, "Error fetching %s: %s", rawURL, resp.Status)
+	}
+
+	body := &countingReader{reader: resp.Body}
+	result := &fileResult{vocab: make(map[string]int)}
+	proc := t.newProcessor(documentName(rawURL, resp.Header.Get("Content-Type")), result)
+	stopRead := t.timeStage(timingRead)
+	reader, err := proc.Process(body)
+	stopRead()
+	if err != nil {
+		// Обрыв соединения при чтении тела процессором — временная ошибка
+		var netErr interface{ Timeout() bool }
+		transient := errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+		return fail(FailureFormat, transient, "Error processing %s: %v", rawURL, err)
+	}
+	defer reader.Close()
+
+	invalidTokens, err := t.scanText(ctx, reader, rawURL, result)
+	if err != nil {
+		return fail(FailureRead, true, "Error reading %s: %v", rawURL, err)
+	}
+	t.logInvalidUTF8(rawURL, invalidTokens)
+	return result, body.n, nil
+}
+
+// Имя документа для выбора процессора: последний элемент пути URL, к которому
+// добавляется расширение по Content-Type, если в пути нет известного расширения
+func documentName(rawURL, contentType string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if strings.HasSuffix(name, ".gz") || knownExtensions[strings.ToLower(path.Ext(name))] {
+		return name
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return name
+	}
+	return name + contentTypeExtensions[mediaType]
+}
+
+// Чтение с подсчетом прочитанных байтов
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы обработки токенов с некорректным UTF-8
+const (
+	InvalidUTF8Keep  = "keep"  // Оставлять токены как есть
+	InvalidUTF8Drop  = "drop"  // Отбрасывать токены
+	InvalidUTF8Strip = "strip" // Удалять некорректные байты и символы замены U+FFFD
+)
+
+// Проверка режима обработки некорректного UTF-8
+func validInvalidUTF8Mode(mode string) bool {
+	switch mode {
+	case "", InvalidUTF8Keep, InvalidUTF8Drop, InvalidUTF8Strip:
+		return true
+	}
+	return false
+}
+
+// Проверка, содержит ли токен некорректный UTF-8 или символ замены
+func hasInvalidUTF8(token string) bool {
+	return !utf8.ValidString(token) || strings.ContainsRune(token, utf8.RuneError)
+}
+
+// Обработка токена с некорректным UTF-8 согласно режиму InvalidUTF8.
+// Возвращает токен, признак того, что токен нужно сохранить, и признак того, что токен был некорректным.
+func (t *Tokenizer) checkUTF8(token string) (string, bool, bool) {
+	if t.opts.InvalidUTF8 == "" || t.opts.InvalidUTF8 == InvalidUTF8Keep || !hasInvalidUTF8(token) {
+		return token, true, false
+	}
+	if t.opts.InvalidUTF8 == InvalidUTF8Drop {
+		return "", false, true
+	}
+
+	token = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError {
+			return -1 // Некорректные байты при переборе также дают RuneError
+		}
+		return r
+	}, token)
+	return token, token != "", true
+}
+
+// Запись в лог числа токенов с некорректным UTF-8
+func (t *Tokenizer) logInvalidUTF8(source string, count int) {
+	if count == 0 {
+		return
+	}
+	t.logError(fmt.Sprintf("Found %d tokens with invalid UTF-8 in %s (mode: %s)", count, source, t.opts.InvalidUTF8))
+}
@@ -0,0 +1,73 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// VocabularyProblem описывает некорректную строку файла словаря
+type VocabularyProblem struct {
+	Line   int    // Номер строки, начиная с 1
+	Text   string // Содержимое строки
+	Reason string // Описание ошибки
+}
+
+func (p VocabularyProblem) String() string {
+	return fmt.Sprintf("line %d: %s: %q", p.Line, p.Reason, p.Text)
+}
+
+// ValidateVocabulary проверяет файл словаря в любом формате, который читает LoadVocabulary
+// (текст "токен частота" или "частота токен" при InputOrder count-token, CSV, TSV, JSON):
+// число полей, целые неотрицательные частоты и повторяющиеся токены. Возвращает найденные
+// проблемы и исправленный словарь, в котором некорректные записи отброшены, а частоты
+// повторяющихся токенов суммированы. Для CSV, TSV и JSON номер строки — номер записи.
+func (t *Tokenizer) ValidateVocabulary(filePath string) ([]VocabularyProblem, map[string]int, error) {
+	format, err := DetectVocabularyFormat(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening vocabulary file: %v", err)
+	}
+	file, err := openVocabFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening vocabulary file: %v", err)
+	}
+	defer file.Close()
+
+	var problems []VocabularyProblem
+	vocab := make(map[string]int)
+	firstLine := make(map[string]int) // Строка первого вхождения токена
+	err = t.readVocabRecords(file, format, func(r vocabRecord) error {
+		problem := func(format string, a ...interface{}) {
+			problems = append(problems, VocabularyProblem{Line: r.line, Text: r.text, Reason: fmt.Sprintf(format, a...)})
+		}
+
+		if r.fields != 2 {
+			problem("expected 2 fields, got %d", r.fields)
+			return nil
+		}
+		count, err := strconv.Atoi(r.countText)
+		switch {
+		case r.token == "":
+			problem("empty token")
+			return nil
+		case err != nil:
+			problem("count is not an integer")
+			return nil
+		case count < 0:
+			problem("negative count")
+			return nil
+		}
+
+		if first, ok := firstLine[r.token]; ok {
+			problem("duplicate token (first seen on line %d)", first)
+		} else {
+			firstLine[r.token] = r.line
+		}
+		vocab[r.token] += count
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s vocabulary file: %v", format, err)
+	}
+
+	return problems, vocab, nil
+}
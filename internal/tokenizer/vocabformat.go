@@ -0,0 +1,254 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Форматы файлов словаря, распознаваемые при загрузке
+const (
+	VocabFormatText = "text" // Строки "токен частота" (порядок полей — InputOrder)
+	VocabFormatCSV  = "csv"  // Два столбца через запятую, необязательная строка заголовка
+	VocabFormatTSV  = "tsv"  // Два столбца через табуляцию, необязательная строка заголовка
+	VocabFormatJSON = "json" // Объект {"токен": частота} или массив [{"token": ..., "count": ...}]
+)
+
+// Объем начала файла, по которому распознается формат
+const vocabSniffSize = 4096
+
+// DetectVocabularyFormat определяет формат файла словаря по расширению (.txt, .csv, .tsv,
+// .json), а при другом расширении — по содержимому начала файла. Для сжатых файлов .gz
+// учитывается расширение без .gz и распакованное содержимое.
+func DetectVocabularyFormat(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(filePath, ".gz"))) {
+	case ".txt", ".vocab":
+		return VocabFormatText, nil
+	case ".csv":
+		return VocabFormatCSV, nil
+	case ".tsv":
+		return VocabFormatTSV, nil
+	case ".json":
+		return VocabFormatJSON, nil
+	}
+
+	file, err := openVocabFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	head := make([]byte, vocabSniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return sniffVocabFormat(head[:n]), nil
+}
+
+// Открытие файла словаря; файлы .gz прозрачно распаковываются
+func openVocabFile(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(filePath, ".gz") {
+		return file, nil
+	}
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error decompressing %s: %v", filePath, err)
+	}
+	return &gzipFile{Reader: gzReader, file: file}, nil
+}
+
+// Распаковываемый файл: закрытие освобождает и распаковщик, и файл
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// Распознавание формата по началу файла: JSON начинается с { или [, строки из двух
+// полей через табуляцию или запятую без пробелов-разделителей — TSV и CSV, иначе текст
+func sniffVocabFormat(head []byte) string {
+	trimmed := bytes.TrimSpace(head)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return VocabFormatJSON
+	}
+	line, _, _ := bytes.Cut(trimmed, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	switch {
+	case bytes.Count(line, []byte("\t")) == 1:
+		return VocabFormatTSV
+	case bytes.Count(line, []byte(" ")) != 1 && bytes.Count(line, []byte(",")) >= 1:
+		return VocabFormatCSV
+	}
+	return VocabFormatText
+}
+
+// Запись файла словаря
+type vocabRecord struct {
+	line      int    // Номер строки (записи), начиная с 1
+	text      string // Исходный текст записи
+	token     string
+	countText string
+	fields    int // Число полей; токен и частота заполнены только при двух полях
+}
+
+// Чтение записей словаря в формате format: fn вызывается для каждой записи из двух полей
+// с номером строки (записи), токеном и текстом частоты. Записи с другим числом полей пропускаются.
+func (t *Tokenizer) readVocabEntries(reader io.Reader, format string, fn func(line int, token, countText string) error) error {
+	return t.readVocabRecords(reader, format, func(r vocabRecord) error {
+		if r.fields != 2 {
+			return nil // Пропускаем некорректные строки
+		}
+		return fn(r.line, r.token, r.countText)
+	})
+}
+
+// Чтение всех записей словаря в формате format, включая записи с другим числом полей
+func (t *Tokenizer) readVocabRecords(reader io.Reader, format string, fn func(r vocabRecord) error) error {
+	switch format {
+	case VocabFormatCSV, VocabFormatTSV:
+		return t.readDelimitedEntries(reader, format == VocabFormatTSV, fn)
+	case VocabFormatJSON:
+		return readJSONEntries(reader, fn)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		token, countText, fields := t.splitEntry(line)
+		if err := fn(vocabRecord{line: lineNumber, text: line, token: token, countText: countText, fields: fields}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Чтение словаря CSV или TSV. Первая строка, в которой частота не является числом,
+// считается заголовком и пропускается.
+func (t *Tokenizer) readDelimitedEntries(reader io.Reader, tabs bool, fn func(r vocabRecord) error) error {
+	r := csv.NewReader(reader)
+	if tabs {
+		r.Comma = '\t'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	for recordNumber := 1; ; recordNumber++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		text := strings.Join(record, string(r.Comma))
+		if len(record) != 2 {
+			if err := fn(vocabRecord{line: recordNumber, text: text, fields: len(record)}); err != nil {
+				return err
+			}
+			continue
+		}
+		token, countText := record[0], strings.TrimSpace(record[1])
+		if t.opts.InputOrder == InputOrderCountToken {
+			token, countText = record[1], strings.TrimSpace(record[0])
+		}
+		if recordNumber == 1 {
+			if _, err := strconv.ParseFloat(countText, 64); err != nil {
+				continue // Заголовок
+			}
+		}
+		if err := fn(vocabRecord{line: recordNumber, text: text, token: token, countText: countText, fields: 2}); err != nil {
+			return err
+		}
+	}
+}
+
+// Чтение словаря JSON: объект {"токен": частота} или массив объектов {"token": ..., "count": ...}.
+// Номер записи отсчитывается с 1 в порядке следования в файле.
+func readJSONEntries(reader io.Reader, fn func(r vocabRecord) error) error {
+	dec := json.NewDecoder(bufio.NewReader(reader))
+	dec.UseNumber()
+
+	start, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	entry := 0
+	switch start {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			var count json.RawMessage
+			if err := dec.Decode(&count); err != nil {
+				return err
+			}
+			entry++
+			token := key.(string)
+			text := fmt.Sprintf("%q: %s", token, count)
+			if err := fn(vocabRecord{line: entry, text: text, token: token, countText: jsonCountText(count), fields: 2}); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			var item struct {
+				Token string          `json:"token"`
+				Count json.RawMessage `json:"count"`
+			}
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			entry++
+			text := fmt.Sprintf(`{"token": %q, "count": %s}`, item.Token, item.Count)
+			if err := fn(vocabRecord{line: entry, text: text, token: item.Token, countText: jsonCountText(item.Count), fields: 2}); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("expected a JSON object or array")
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// Текст частоты из значения JSON: число записывается как есть, строка — без кавычек
+func jsonCountText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	return string(raw)
+}
+
+// Вывод распознанных форматов входных файлов словаря
+func (t *Tokenizer) reportVocabFormats(filePaths []string) {
+	for _, filePath := range filePaths {
+		format, err := DetectVocabularyFormat(filePath)
+		if err != nil {
+			continue // Ошибка открытия будет выведена при загрузке
+		}
+		fmt.Fprintf(t.out, "%s: %s\n", filePath, format)
+	}
+}
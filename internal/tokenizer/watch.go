@@ -0,0 +1,177 @@
+package tokenizer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Состояние файла отслеживаемой директории
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	vocab   map[string]int // Вклад файла в словарь (nil, если файл не удалось обработать)
+}
+
+// Проверка, что размер и время изменения файла совпадают с запомненными
+func (f *watchedFile) same(info os.FileInfo) bool {
+	return f.size == info.Size() && f.modTime.Equal(info.ModTime())
+}
+
+// WatchDirectory строит словарь файлов директории и затем следит за ней до отмены ctx:
+// каждые interval директория просматривается, новые и измененные файлы обрабатываются,
+// а вклад удаленных и измененных файлов вычитается из словаря. Файл обрабатывается только
+// после того, как его размер и время изменения не менялись в течение одного интервала,
+// поэтому частые изменения дописываемого файла не вызывают повторной обработки.
+// После начального построения и после каждого обновления словарь передается в flush.
+// Для вычитания вклад каждого файла хранится в памяти.
+func (t *Tokenizer) WatchDirectory(ctx context.Context, dirPath string, maxGoroutines int, interval time.Duration, flush func(vocab map[string]int) error) error {
+	files := make(map[string]*watchedFile)
+	pending := make(map[string]*watchedFile) // Измененные файлы, ожидающие окончания записи
+	vocab := make(map[string]int)
+
+	update := func(initial bool) error {
+		entries, err := os.ReadDir(dirPath)
+		if err != nil {
+			return fmt.Errorf("error reading directory %s: %v", dirPath, err)
+		}
+
+		seen := make(map[string]bool, len(entries))
+		var ready []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			// Об исключенных файлах сообщается только при начальном просмотре
+			if pattern, ok := t.excludedBy(dirPath, entry.Name()); ok {
+				if initial {
+					t.logError(fmt.Sprintf("Skipped excluded file %s (matches %s)", filepath.Join(dirPath, entry.Name()), pattern))
+				}
+				continue
+			}
+			if t.opts.Sample > 0 && t.opts.Sample < 1 && !t.sampled(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue // Файл удален между чтением директории и Info
+			}
+			filePath := filepath.Join(dirPath, entry.Name())
+			seen[filePath] = true
+
+			if known, ok := files[filePath]; ok && known.same(info) {
+				delete(pending, filePath)
+				continue
+			}
+			if waiting, ok := pending[filePath]; initial || ok && waiting.same(info) {
+				delete(pending, filePath)
+				ready = append(ready, filePath)
+				continue
+			}
+			pending[filePath] = &watchedFile{size: info.Size(), modTime: info.ModTime()}
+		}
+
+		// Удаленные файлы
+		removed := 0
+		for filePath, file := range files {
+			if !seen[filePath] {
+				subtractVocab(vocab, file.vocab)
+				delete(files, filePath)
+				removed++
+			}
+		}
+		for filePath := range pending {
+			if !seen[filePath] {
+				delete(pending, filePath)
+			}
+		}
+
+		// Новые и измененные файлы
+		added, changed := 0, 0
+		for filePath, file := range t.watchFiles(ready, maxGoroutines) {
+			if old, ok := files[filePath]; ok {
+				subtractVocab(vocab, old.vocab)
+				changed++
+			} else {
+				added++
+			}
+			for token, count := range file.vocab {
+				vocab[token] += count
+			}
+			files[filePath] = file
+		}
+
+		if !initial && added+changed+removed == 0 {
+			return nil
+		}
+		fmt.Fprintf(t.out, "%s Vocabulary updated: %d new, %d changed, %d removed files; %d files, %d tokens\n",
+			time.Now().Format("2006/01/02 15:04:05"), added, changed, removed, len(files), len(vocab))
+		if t.opts.FoldCase {
+			return flush(foldCase(vocab))
+		}
+		return flush(vocab)
+	}
+
+	if err := update(true); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := update(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Параллельная обработка файлов, найденных при просмотре директории.
+// Файлы, которые не удалось обработать, запоминаются без словаря и не обрабатываются
+// повторно, пока не изменятся.
+func (t *Tokenizer) watchFiles(filePaths []string, maxGoroutines int) map[string]*watchedFile {
+	results := make(map[string]*watchedFile, len(filePaths))
+	var mutex sync.Mutex
+	guard := make(chan struct{}, maxGoroutines)
+	var wg sync.WaitGroup
+	for _, filePath := range filePaths {
+		wg.Add(1)
+		go func(filePath string) {
+			defer wg.Done()
+			guard <- struct{}{}
+			defer func() { <-guard }()
+
+			// Состояние запоминается до чтения, чтобы дописанный во время обработки файл
+			// был обработан повторно
+			info, err := os.Stat(filePath)
+			if err != nil {
+				return
+			}
+			file := &watchedFile{size: info.Size(), modTime: info.ModTime()}
+			if result, ok := t.processFileWithTimeout(filePath); ok {
+				file.vocab = result.vocab
+			}
+
+			mutex.Lock()
+			results[filePath] = file
+			mutex.Unlock()
+		}(filePath)
+	}
+	wg.Wait()
+	return results
+}
+
+// Вычитание вклада файла из словаря; токены с нулевой частотой удаляются
+func subtractVocab(vocab, part map[string]int) {
+	for token, count := range part {
+		if vocab[token] -= count; vocab[token] <= 0 {
+			delete(vocab, token)
+		}
+	}
+}
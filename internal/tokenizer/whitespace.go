@@ -0,0 +1,29 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Проверка, является ли руна пробельной или невидимой (пробелы нулевой ширины, BOM)
+func isInvisibleSpace(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
+
+// Удаление пробельных и невидимых символов внутри и по краям токена
+// (например, пробела нулевой ширины перед словом или внутри него)
+func normalizeWhitespace(token string) string {
+	if strings.IndexFunc(token, isInvisibleSpace) < 0 {
+		return token
+	}
+	return strings.Map(func(r rune) rune {
+		if isInvisibleSpace(r) {
+			return -1
+		}
+		return r
+	}, token)
+}
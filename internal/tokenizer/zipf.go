@@ -0,0 +1,65 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// Сохранение распределения ранг-частота (строки "ранг частота токен").
+// Возвращает показатель степени закона Ципфа, оцененный линейной регрессией в логарифмических координатах.
+func (t *Tokenizer) SaveZipf(vocab map[string]int, outputFile string) (float64, error) {
+	fmt.Fprintln(t.out, "Saving rank-frequency distribution...")
+	type TokenFrequency struct {
+		Token string
+		Count int
+	}
+	tokenFrequencies := make([]TokenFrequency, 0, len(vocab))
+	for token, count := range vocab {
+		tokenFrequencies = append(tokenFrequencies, TokenFrequency{Token: token, Count: count})
+	}
+	sort.Slice(tokenFrequencies, func(i, j int) bool {
+		if tokenFrequencies[i].Count != tokenFrequencies[j].Count {
+			return tokenFrequencies[i].Count > tokenFrequencies[j].Count
+		}
+		return tokenFrequencies[i].Token < tokenFrequencies[j].Token
+	})
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error creating output file %s: %v", outputFile, err))
+		return 0, fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Close()
+
+	// Суммы для регрессии log(частота) = a + b*log(ранг)
+	var sumX, sumY, sumXY, sumXX float64
+	n := 0
+
+	writer := bufio.NewWriter(file)
+	for i, tf := range tokenFrequencies {
+		rank := i + 1
+		fmt.Fprintf(writer, "%d %d %s\n", rank, tf.Count, tf.Token)
+
+		if tf.Count > 0 {
+			x, y := math.Log(float64(rank)), math.Log(float64(tf.Count))
+			sumX += x
+			sumY += y
+			sumXY += x * y
+			sumXX += x * x
+			n++
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, fmt.Errorf("error writing file: %v", err)
+	}
+
+	denominator := float64(n)*sumXX - sumX*sumX
+	if n < 2 || denominator == 0 {
+		return 0, nil
+	}
+	slope := (float64(n)*sumXY - sumX*sumY) / denominator
+	return -slope, nil
+}
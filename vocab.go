@@ -0,0 +1,192 @@
+// Package vocab — публичный API для построения частотных словарей.
+//
+// Пакет дает доступ к тому же механизму подсчета, что и команда vocab:
+//
+//	v, err := vocab.Build("./books", vocab.Options{Lowercase: true, FilterPunct: true})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = vocab.Save(v, "vocab.txt", vocab.SortFreq)
+//
+// Стабильными считаются типы Vocabulary, Options, SortOrder, ErrorMode и TokenFilter и функции Build,
+// BuildReader, Load, Merge, Process и Save: их сигнатуры и поведение не меняются несовместимо. Поля Options
+// могут только добавляться. Остальная функциональность команды (форматы, фильтры,
+// обучение BPE и т.д.) находится во внутренних пакетах и может меняться.
+//
+// По умолчанию функции не создают файлов кроме запрошенных и ничего не выводят: лог ошибок
+// обработки файлов и копии проблемных файлов, как у команды, включаются полем ErrorMode,
+// а предупреждения выводятся в Diagnostics.
+package vocab
+
+import (
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/terratensor/vocab/internal/tokenizer"
+)
+
+// Vocabulary — частотный словарь: токен и число его вхождений
+type Vocabulary map[string]int
+
+// SortOrder — порядок записи словаря в файл
+type SortOrder string
+
+const (
+	SortNone  SortOrder = ""      // Без сортировки
+	SortFreq  SortOrder = "freq"  // По убыванию частоты
+	SortAlpha SortOrder = "alpha" // По алфавиту
+)
+
+// ErrorMode — обработка файлов, которые не удалось прочитать при построении словаря
+type ErrorMode string
+
+const (
+	ErrorModeNone ErrorMode = "none" // Не вести лог и не создавать папку ошибок (по умолчанию)
+	ErrorModeList ErrorMode = "list" // Записывать пути файлов и ошибки в лог в папке ErrorDir
+	ErrorModeCopy ErrorMode = "copy" // Записывать ошибки в лог и копировать файлы в папку ErrorDir
+)
+
+// TokenFilter преобразует токен перед подсчетом; false означает, что токен нужно отбросить.
+// Фильтр вызывается из нескольких горутин одновременно.
+type TokenFilter func(token string) (string, bool)
+
+// Options задает обработку токенов
+type Options struct {
+	Lowercase   bool // Приводить токены к нижнему регистру
+	FilterPunct bool // Отбрасывать токены из знаков препинания
+	Workers     int  // Число файлов или частей словаря, обрабатываемых параллельно (по умолчанию — число процессоров)
+	Verbose     bool // Выводить прогресс в stdout
+
+	ErrorMode ErrorMode // Обработка файлов с ошибками (по умолчанию ErrorModeNone)
+	ErrorDir  string    // Папка для лога ошибок и копий файлов (по умолчанию vocab_errors)
+
+	// Вывод предупреждений и сводки файлов, которые не удалось обработать
+	// (nil — stderr при Verbose, иначе не выводить)
+	Diagnostics io.Writer
+
+	// Пользовательские фильтры. Применяются по порядку после встроенных преобразований
+	// (Lowercase, FilterPunct); первый фильтр, вернувший false, отбрасывает токен.
+	Filters []TokenFilter
+
+	// Вызывается во время построения словаря (Build, BuildReader) с копией текущих
+	// частот не чаще CountsInterval (по умолчанию раз в секунду), например для вывода
+	// самых частых токенов по ходу подсчета. Копию можно хранить и изменять; вызовы
+	// не происходят одновременно.
+	OnCounts       func(counts Vocabulary)
+	CountsInterval time.Duration
+}
+
+// Создание внутреннего токенизатора с параметрами публичного API
+func newTokenizer(opts Options) (*tokenizer.Tokenizer, error) {
+	var onCounts func(counts map[string]int)
+	if opts.OnCounts != nil {
+		onCounts = func(counts map[string]int) { opts.OnCounts(counts) }
+	}
+	errorMode := opts.ErrorMode
+	if errorMode == "" {
+		errorMode = ErrorModeNone
+	}
+	diagnostics := opts.Diagnostics
+	if diagnostics == nil && !opts.Verbose {
+		diagnostics = io.Discard
+	}
+	t, err := tokenizer.NewTokenizer(tokenizer.Options{
+		Lowercase:      opts.Lowercase,
+		FilterPunct:    opts.FilterPunct,
+		MaxGoroutines:  workers(opts),
+		Quiet:          !opts.Verbose,
+		ErrorMode:      string(errorMode),
+		ErrorDir:       opts.ErrorDir,
+		Diagnostics:    diagnostics,
+		OnCounts:       onCounts,
+		CountsInterval: opts.CountsInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, filter := range opts.Filters {
+		t.AddFilter(tokenizer.TokenFilter(filter))
+	}
+	return t, nil
+}
+
+// Build строит словарь из файлов директории dir
+func Build(dir string, opts Options) (Vocabulary, error) {
+	t, err := newTokenizer(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	return t.BuildVocabulary(dir, workers(opts))
+}
+
+// BuildReader строит словарь из текста, читаемого из r. Поток читается и считается
+// по мере поступления, поэтому r может быть, например, stdin или телом HTTP-ответа.
+// Строки из одного слова учитываются, в отличие от текстовых файлов в Build.
+func BuildReader(r io.Reader, opts Options) (Vocabulary, error) {
+	t, err := newTokenizer(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	return t.BuildReaderVocabulary(r, "stream")
+}
+
+// Число параллельных горутин: Workers или число процессоров
+func workers(opts Options) int {
+	if opts.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return opts.Workers
+}
+
+// Load загружает словарь из файла со строками "токен частота"
+func Load(path string) (Vocabulary, error) {
+	t, err := newTokenizer(Options{})
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	return t.LoadVocabulary(path)
+}
+
+// Merge объединяет словари из файлов, суммируя частоты, и применяет к токенам opts
+func Merge(paths []string, opts Options) (Vocabulary, error) {
+	t, err := newTokenizer(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	merged, err := t.MergeVocabularies(paths)
+	if err != nil {
+		return nil, err
+	}
+	return t.ProcessVocabulary(merged), nil
+}
+
+// Process применяет к токенам готового словаря opts (регистр, фильтрация пунктуации, фильтры)
+func Process(v Vocabulary, opts Options) (Vocabulary, error) {
+	t, err := newTokenizer(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	return t.ProcessVocabulary(v), nil
+}
+
+// Save сохраняет словарь в файл строками "токен частота"; путь "-" означает stdout
+func Save(v Vocabulary, path string, order SortOrder) error {
+	t, err := newTokenizer(Options{})
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	return t.SaveVocabulary(v, path, string(order))
+}
@@ -0,0 +1,150 @@
+package vocab_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/terratensor/vocab"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func checkVocabulary(t *testing.T, name string, got, want vocab.Vocabulary) {
+	t.Helper()
+	if !maps.Equal(got, want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+// Build считает токены файлов директории; ни лог ошибок, ни другие файлы не создаются
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.txt", "Кот и пес.\nКот спит\n")
+	writeFile(t, dir, "b.txt", "Пес лает, кот спит.\n")
+	t.Chdir(t.TempDir())
+
+	v, err := vocab.Build(dir, vocab.Options{Lowercase: true, FilterPunct: true, Workers: 2})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	checkVocabulary(t, "Build", v, vocab.Vocabulary{"кот": 3, "и": 1, "пес": 2, "спит": 2, "лает": 1})
+
+	if entries, err := os.ReadDir("."); err != nil || len(entries) != 0 {
+		t.Errorf("Build created files in the working directory: %v, %v", entries, err)
+	}
+	if _, err := vocab.Build(filepath.Join(dir, "missing"), vocab.Options{}); err == nil {
+		t.Error("Build of a missing directory succeeded, want an error")
+	}
+}
+
+// BuildReader учитывает и строки из одного слова
+func TestBuildReader(t *testing.T) {
+	v, err := vocab.BuildReader(strings.NewReader("hello\nworld foo\n2019\nИтого\n"), vocab.Options{})
+	if err != nil {
+		t.Fatalf("BuildReader: %v", err)
+	}
+	checkVocabulary(t, "BuildReader", v, vocab.Vocabulary{"hello": 1, "world": 1, "foo": 1, "2019": 1, "Итого": 1})
+}
+
+// Пользовательские фильтры применяются по порядку после встроенных преобразований
+func TestOptionsFilters(t *testing.T) {
+	var seen []string
+	opts := vocab.Options{
+		Lowercase: true,
+		Workers:   1,
+		Filters: []vocab.TokenFilter{
+			func(token string) (string, bool) {
+				seen = append(seen, token)
+				return token, len([]rune(token)) > 2
+			},
+			func(token string) (string, bool) { return strings.TrimSuffix(token, "ы"), true },
+		},
+	}
+	v, err := vocab.BuildReader(strings.NewReader("Коты и КОТ"), opts)
+	if err != nil {
+		t.Fatalf("BuildReader: %v", err)
+	}
+	checkVocabulary(t, "BuildReader with filters", v, vocab.Vocabulary{"кот": 2})
+	if want := []string{"коты", "и", "кот"}; !slices.Equal(seen, want) {
+		t.Errorf("first filter saw %q, want lowercased tokens %q", seen, want)
+	}
+
+	p, err := vocab.Process(vocab.Vocabulary{"Коты": 2, "ИЛИ": 1, "до": 5}, opts)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	checkVocabulary(t, "Process with filters", p, vocab.Vocabulary{"кот": 2, "или": 1})
+}
+
+// OnCounts получает копию частот, изменение которой не влияет на результат
+func TestOptionsOnCounts(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.txt", "кот спит\nпес лает\n")
+	var calls int
+	var last vocab.Vocabulary
+	v, err := vocab.Build(dir, vocab.Options{
+		OnCounts: func(counts vocab.Vocabulary) {
+			calls++
+			last = counts
+			counts["изменено"] = 100
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if calls == 0 {
+		t.Fatal("OnCounts was not called")
+	}
+	for token, count := range last {
+		if token != "изменено" && count > v[token] {
+			t.Errorf("OnCounts reported %s = %d, more than the final %d", token, count, v[token])
+		}
+	}
+	if _, ok := v["изменено"]; ok {
+		t.Errorf("Build = %v, changes to the OnCounts copy leaked into the result", v)
+	}
+}
+
+// Save, Load и Merge: запись и чтение строк "токен частота" и суммирование частот
+func TestSaveLoadMerge(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	if err := vocab.Save(vocab.Vocabulary{"кот": 3, "пес": 5, "еж": 1}, first, vocab.SortFreq); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "пес 5\nкот 3\nеж 1\n" {
+		t.Errorf("Save wrote %q, want tokens by descending frequency", data)
+	}
+
+	loaded, err := vocab.Load(first)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	checkVocabulary(t, "Load", loaded, vocab.Vocabulary{"кот": 3, "пес": 5, "еж": 1})
+
+	second := writeFile(t, dir, "second.txt", "Кот 2\n, 4\n")
+	merged, err := vocab.Merge([]string{first, second}, vocab.Options{Lowercase: true, FilterPunct: true})
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	checkVocabulary(t, "Merge", merged, vocab.Vocabulary{"кот": 5, "пес": 5, "еж": 1})
+
+	if _, err := vocab.Load(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Load of a missing file succeeded, want an error")
+	}
+}